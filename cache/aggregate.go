@@ -0,0 +1,65 @@
+package cache
+
+// scan calls fn for each item matching the condition in a single pass under
+// the read lock, answering indexed conditions from the index. It reports
+// false if the cache may not serve its data, e.g. because it has expired.
+// fn must not call back into the cache; its panics are recovered like those
+// of the condition.
+func (cm *CacheManager[T]) scan(condition QueryCondition[T], fn func(T)) bool {
+	g, report := cm.guard()
+	defer report()
+	guarded := recoverCondition(condition, g)
+	result := cm.executeWithLock(true, func() interface{} {
+		if !cm.listAllowed() {
+			return false
+		}
+		if ids, ok := cm.indexCandidates(condition); ok {
+			for id := range ids {
+				if item := cm.data[id]; guarded.Match(item) {
+					g.call("aggregate func", func() { fn(item) })
+				}
+			}
+			return true
+		}
+		for _, item := range cm.data {
+			if guarded.Match(item) {
+				g.call("aggregate func", func() { fn(item) })
+			}
+		}
+		return true
+	})
+	return result.(bool)
+}
+
+// Count returns the number of items matching the condition without
+// materializing them. An expired or never refreshed cache counts 0.
+func (cm *CacheManager[T]) Count(condition QueryCondition[T]) int {
+	n := 0
+	cm.scan(condition, func(T) { n++ })
+	return n
+}
+
+// SumBy returns the sum of extractor over the items matching the
+// condition, e.g. the order total of a user, in a single pass under the
+// read lock. An expired or never refreshed cache sums to 0.
+func SumBy[T Identifiable, N Number](cm *CacheManager[T], condition QueryCondition[T], extractor func(T) N) N {
+	var sum N
+	cm.scan(condition, func(item T) { sum += extractor(item) })
+	return sum
+}
+
+// AvgBy returns the average of extractor over the items matching the
+// condition. ok is false if no item matches or the cache may not serve its
+// data.
+func AvgBy[T Identifiable, N Number](cm *CacheManager[T], condition QueryCondition[T], extractor func(T) N) (avg float64, ok bool) {
+	var sum float64
+	n := 0
+	cm.scan(condition, func(item T) {
+		sum += float64(extractor(item))
+		n++
+	})
+	if n == 0 {
+		return 0, false
+	}
+	return sum / float64(n), true
+}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregates(t *testing.T) {
+	cache := NewCacheManager[models.Order](&mockOrderLoader{orders: []models.Order{
+		{ID: 1, UserID: 1, Amount: 100},
+		{ID: 2, UserID: 1, Amount: 250},
+		{ID: 3, UserID: 2, Amount: 300},
+	}}).WithTTL(time.Minute)
+	user1 := NumberFieldCondition[models.Order, uint]{FieldExtractor: func(o models.Order) uint { return o.UserID }, Value: 1, Operation: "eq"}
+	user9 := NumberFieldCondition[models.Order, uint]{FieldExtractor: func(o models.Order) uint { return o.UserID }, Value: 9, Operation: "eq"}
+	amount := func(o models.Order) float64 { return o.Amount }
+
+	assert.Zero(t, cache.Count(user1), "a never refreshed cache counts nothing")
+	assert.NoError(t, cache.Refresh())
+
+	assert.Equal(t, 2, cache.Count(user1))
+	assert.Equal(t, float64(350), SumBy(cache, user1, amount))
+	assert.Equal(t, uint(1+1+2), SumBy(cache, Not[models.Order](user9), func(o models.Order) uint { return o.UserID }))
+
+	avg, ok := AvgBy(cache, user1, amount)
+	assert.True(t, ok)
+	assert.Equal(t, float64(175), avg)
+	_, ok = AvgBy(cache, user9, amount)
+	assert.False(t, ok, "no matches")
+
+	t.Run("expired caches aggregate to zero", func(t *testing.T) {
+		cache.lastFetch = time.Now().Add(-2 * time.Minute)
+		assert.Zero(t, cache.Count(user1))
+		assert.Zero(t, SumBy(cache, user1, amount))
+		_, ok := AvgBy(cache, user1, amount)
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,112 @@
+package cache
+
+import "time"
+
+// RefreshResult counts how a refresh changed the cached dataset
+type RefreshResult struct {
+	Added   int // items present only in the new dataset
+	Removed int // items present only in the old dataset
+	Updated int // items present in both with differing values
+}
+
+// OnRefresh registers a hook called after every Refresh and Apply with the
+// number of added, removed and changed items. Hooks run after the lock is
+// released, so they may read the cache. Computing the counts compares every
+// item, so a Refresh only pays for it once a hook is registered.
+func (cm *CacheManager[T]) OnRefresh(hook func(added, removed, changed int)) *CacheManager[T] {
+	cm.onRefresh = append(cm.onRefresh, hook)
+	return cm
+}
+
+// WithChangeHook registers a hook called after every Refresh and Apply that
+// changed the dataset, with the items added, updated (new values) and
+// removed (old values), each in ascending ID order. It suits invalidating
+// downstream caches or pushing updates to clients. Like OnRefresh, hooks
+// run after the lock is released and a Refresh only diffs the datasets
+// once a hook is registered.
+func (cm *CacheManager[T]) WithChangeHook(hook func(added, updated, removed []T)) *CacheManager[T] {
+	cm.onChange = append(cm.onChange, hook)
+	return cm
+}
+
+// WithEquals sets how Apply, OnRefresh, WithChangeHook and Verify decide whether an item
+// changed. The default is reflect.DeepEqual, which handles structs that are
+// not comparable with ==, such as ones holding slices; a hand written
+// comparison, e.g. of an UpdatedAt field, is usually much faster.
+func (cm *CacheManager[T]) WithEquals(equals func(a, b T) bool) *CacheManager[T] {
+	cm.equals = equals
+	return cm
+}
+
+// Apply atomically replaces the cached dataset with items, e.g. for updates
+// pushed over a message bus, and reports the changes against the previous
+// dataset. It counts as a refresh for the TTL.
+func (cm *CacheManager[T]) Apply(items []T) RefreshResult {
+	result := cm.executeWithLock(false, func() interface{} {
+		return cm.swapData(items, time.Now(), true)
+	}).(refreshChanges[T])
+
+	cm.fireRefresh(result)
+	return result.RefreshResult
+}
+
+// refreshChanges is the outcome of swapData: the change counts and, when a
+// change hook is registered, the changed items
+type refreshChanges[T any] struct {
+	RefreshResult
+	added, updated, removed []T
+}
+
+// swapData replaces the dataset and marks the cache fetched. The changes are
+// computed against the pre-swap data when diff is set or a hook or
+// subscriber needs them.
+// Callers must hold the write lock.
+func (cm *CacheManager[T]) swapData(items []T, at time.Time, diff bool) refreshChanges[T] {
+	old := cm.data
+	first := cm.lastFetch.IsZero()
+	cm.setData(items, at)
+	cm.markFetched(at)
+	if first {
+		cm.events.emit(Event{Kind: EventInit})
+	}
+
+	if !diff && len(cm.onRefresh) == 0 && len(cm.onChange) == 0 && !cm.events.hasSubscribers() {
+		cm.events.emit(Event{Kind: EventRefresh})
+		return refreshChanges[T]{}
+	}
+	g := &callbackGuard{enabled: cm.recoverPanics}
+	d := diffData(old, cm.data, cm.guardedEquals(g))
+	if g.err != nil {
+		cm.recordPanic(g.err)
+	}
+	result := refreshChanges[T]{RefreshResult: RefreshResult{Added: len(d.added), Removed: len(d.removed), Updated: len(d.updated)}}
+	if len(cm.onChange) > 0 {
+		result.added = itemsByID(cm.data, d.added)
+		result.updated = itemsByID(cm.data, d.updated)
+		result.removed = itemsByID(old, d.removed)
+	}
+	cm.events.emit(Event{Kind: EventRefresh, Added: result.Added, Removed: result.Removed, Updated: result.Updated})
+	return result
+}
+
+func itemsByID[T any](data map[uint]T, ids []uint) []T {
+	items := make([]T, len(ids))
+	for i, id := range ids {
+		items[i] = data[id]
+	}
+	return items
+}
+
+// fireRefresh calls the OnRefresh and change hooks. It must be called
+// without the lock.
+func (cm *CacheManager[T]) fireRefresh(result refreshChanges[T]) {
+	for _, hook := range cm.onRefresh {
+		cm.safeCall("OnRefresh hook", func() { hook(result.Added, result.Removed, result.Updated) })
+	}
+	if result.Added+result.Updated+result.Removed == 0 {
+		return
+	}
+	for _, hook := range cm.onChange {
+		cm.safeCall("change hook", func() { hook(result.added, result.updated, result.removed) })
+	}
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheManagerApply(t *testing.T) {
+	loader := &mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John"},
+		{ID: 2, Name: "Jane"},
+		{ID: 3, Name: "Bob"},
+	}}
+
+	var hooked []RefreshResult
+	cache := NewCacheManager[models.User](loader).
+		OnRefresh(func(added, removed, changed int) {
+			hooked = append(hooked, RefreshResult{Added: added, Removed: removed, Updated: changed})
+		})
+	assert.NoError(t, cache.Refresh())
+
+	result := cache.Apply([]models.User{
+		{ID: 1, Name: "John"},     // unchanged
+		{ID: 2, Name: "Jane Doe"}, // updated
+		{ID: 4, Name: "Alice"},    // added
+		{ID: 5, Name: "Eve"},      // added
+	}) // 3 removed
+
+	assert.Equal(t, RefreshResult{Added: 2, Removed: 1, Updated: 1}, result)
+	assert.Equal(t, []RefreshResult{{Added: 3}, result}, hooked)
+
+	assert.Len(t, cache.GetAll(), 4)
+	user, err := cache.Get(2)
+	assert.NoError(t, err)
+	assert.Equal(t, "Jane Doe", user.Name)
+	_, err = cache.Get(3)
+	assert.Error(t, err)
+}
+
+func TestCacheManagerApplyWithoutHooks(t *testing.T) {
+	cache := NewCacheManager[models.User](nil)
+	result := cache.Apply([]models.User{{ID: 1, Name: "John"}})
+	assert.Equal(t, RefreshResult{Added: 1}, result, "Apply always reports the changes")
+
+	_, err := cache.Get(1)
+	assert.NoError(t, err, "Apply initializes the cache")
+}
+
+func TestCacheManagerApplyEquals(t *testing.T) {
+	user := models.UserV2{ID: 1, Name: "John", Orders: []models.Order{{ID: 1, UserID: 1, Amount: 100}}}
+
+	t.Run("detects a changed slice field by default", func(t *testing.T) {
+		cache := NewCacheManager[models.UserV2](nil)
+		cache.Apply([]models.UserV2{user})
+
+		changed := user
+		changed.Orders = []models.Order{{ID: 1, UserID: 1, Amount: 100}, {ID: 2, UserID: 1, Amount: 50}}
+		assert.Equal(t, RefreshResult{Updated: 1}, cache.Apply([]models.UserV2{changed}))
+
+		same := changed
+		same.Orders = append([]models.Order(nil), changed.Orders...)
+		assert.Equal(t, RefreshResult{}, cache.Apply([]models.UserV2{same}), "equal contents are not an update")
+	})
+
+	t.Run("uses the configured equals", func(t *testing.T) {
+		cache := NewCacheManager[models.UserV2](nil).
+			WithEquals(func(a, b models.UserV2) bool { return a.Name == b.Name })
+		cache.Apply([]models.UserV2{user})
+
+		changed := user
+		changed.Orders = nil
+		assert.Equal(t, RefreshResult{}, cache.Apply([]models.UserV2{changed}))
+		changed.Name = "John Smith"
+		assert.Equal(t, RefreshResult{Updated: 1}, cache.Apply([]models.UserV2{changed}))
+	})
+}
+
+func TestCacheManagerChangeHook(t *testing.T) {
+	loader := &mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John"},
+		{ID: 2, Name: "Jane"},
+		{ID: 3, Name: "Bob"},
+	}}
+
+	type change struct{ added, updated, removed []models.User }
+	var changes []change
+	cache := NewCacheManager[models.User](loader).
+		WithChangeHook(func(added, updated, removed []models.User) {
+			changes = append(changes, change{added, updated, removed})
+		})
+
+	assert.NoError(t, cache.Refresh())
+	assert.Equal(t, []change{{added: loader.users, updated: []models.User{}, removed: []models.User{}}}, changes)
+
+	loader.users = []models.User{
+		{ID: 1, Name: "John"},
+		{ID: 2, Name: "Jane Doe"},
+		{ID: 5, Name: "Eve"},
+		{ID: 4, Name: "Alice"},
+	}
+	assert.NoError(t, cache.Refresh())
+	assert.Equal(t, change{
+		added:   []models.User{{ID: 4, Name: "Alice"}, {ID: 5, Name: "Eve"}},
+		updated: []models.User{{ID: 2, Name: "Jane Doe"}},
+		removed: []models.User{{ID: 3, Name: "Bob"}},
+	}, changes[1])
+
+	assert.NoError(t, cache.Refresh())
+	assert.Len(t, changes, 2, "a refresh without changes does not call the hook")
+
+	cache.Apply([]models.User{{ID: 1, Name: "John Smith"}})
+	assert.Len(t, changes, 3)
+	assert.Equal(t, []models.User{{ID: 1, Name: "John Smith"}}, changes[2].updated)
+	assert.Len(t, changes[2].removed, 3)
+}
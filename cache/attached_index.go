@@ -0,0 +1,113 @@
+package cache
+
+// Index is a secondary index the cache keeps in lockstep with its data. The
+// cache calls Add and Remove under its write lock for every item it inserts,
+// replaces or drops, so Set, Delete, Patch and incremental refreshes only
+// touch the affected buckets; a full refresh removes the old items and adds
+// the new ones. Lookup returns the IDs stored under a key.
+type Index[T any] interface {
+	Add(id uint, item T)
+	Remove(id uint, item T)
+	Lookup(key string) []uint
+}
+
+// WithIndex attaches an index under name, e.g. a KeyIndex over a derived
+// value, filling it with the cached items. Attaching an index under an
+// existing name replaces it. Read it through LookupIndex, which holds the
+// read lock the index relies on.
+func (cm *CacheManager[T]) WithIndex(name string, index Index[T]) *CacheManager[T] {
+	cm.executeWithLock(false, func() interface{} {
+		if cm.attached == nil {
+			cm.attached = make(map[string]Index[T])
+		}
+		cm.attached[name] = index
+		for id, item := range cm.data {
+			cm.callLocked("index", func() { index.Add(id, item) })
+		}
+		return nil
+	})
+	return cm
+}
+
+// LookupIndex returns the items stored under key in the index attached as
+// name. An unknown index yields nil.
+func (cm *CacheManager[T]) LookupIndex(name, key string) []T {
+	result := cm.executeWithLock(true, func() interface{} {
+		if !cm.listAllowed() {
+			return unavailableList[T](cm)
+		}
+		index, ok := cm.attached[name]
+		if !ok {
+			return []T(nil)
+		}
+		ids := index.Lookup(key)
+		items := make([]T, 0, len(ids))
+		for _, id := range ids {
+			if item, exists := cm.data[id]; exists {
+				items = append(items, item)
+			}
+		}
+		return items
+	})
+	return cm.sort(result.([]T))
+}
+
+// unindexAttached removes every cached item from the attached indexes before
+// the data is replaced. Callers must hold the write lock.
+func (cm *CacheManager[T]) unindexAttached() {
+	for _, index := range cm.attached {
+		for id, item := range cm.data {
+			cm.callLocked("index", func() { index.Remove(id, item) })
+		}
+	}
+}
+
+// KeyIndex is an Index over a key derived from each item, e.g. an email
+// domain. It is not safe for concurrent use on its own; attached to a cache
+// it is guarded by the cache lock.
+type KeyIndex[T any] struct {
+	key     func(T) string
+	buckets map[string]map[uint]struct{}
+}
+
+// NewKeyIndex creates a KeyIndex that files each item under key(item)
+func NewKeyIndex[T any](key func(T) string) *KeyIndex[T] {
+	return &KeyIndex[T]{key: key, buckets: make(map[string]map[uint]struct{})}
+}
+
+// Add files an item under its key
+func (x *KeyIndex[T]) Add(id uint, item T) {
+	k := x.key(item)
+	bucket, ok := x.buckets[k]
+	if !ok {
+		bucket = make(map[uint]struct{})
+		x.buckets[k] = bucket
+	}
+	bucket[id] = struct{}{}
+}
+
+// Remove drops an item from the bucket of its key, dropping the bucket if it
+// becomes empty
+func (x *KeyIndex[T]) Remove(id uint, item T) {
+	k := x.key(item)
+	bucket := x.buckets[k]
+	delete(bucket, id)
+	if len(bucket) == 0 {
+		delete(x.buckets, k)
+	}
+}
+
+// Lookup returns the IDs filed under key in no particular order
+func (x *KeyIndex[T]) Lookup(key string) []uint {
+	bucket := x.buckets[key]
+	ids := make([]uint, 0, len(bucket))
+	for id := range bucket {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Len returns the number of keys with at least one item
+func (x *KeyIndex[T]) Len() int {
+	return len(x.buckets)
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func emailDomain(u models.User) string {
+	return u.Email[strings.IndexByte(u.Email, '@')+1:]
+}
+
+func TestCacheManagerWithIndex(t *testing.T) {
+	loader := &mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+		{ID: 2, Name: "Jane", Email: "jane@corp.com"},
+	}}
+	domains := NewKeyIndex(emailDomain)
+	cache := NewCacheManager[models.User](loader).WithTTL(time.Minute).
+		WithIndex("domain", domains).
+		WithDeterministicOrder(func(a, b models.User) bool { return a.ID < b.ID })
+	assert.NoError(t, cache.Refresh())
+	assert.Equal(t, 2, domains.Len())
+
+	// consistent checks the index against a recomputation from the data
+	consistent := func(t *testing.T) {
+		t.Helper()
+		want := make(map[string][]models.User)
+		for _, u := range cache.GetAll() {
+			want[emailDomain(u)] = append(want[emailDomain(u)], u)
+		}
+		assert.Equal(t, len(want), domains.Len())
+		for domain, users := range want {
+			assert.Equal(t, users, cache.LookupIndex("domain", domain))
+		}
+	}
+
+	cache.Set(models.User{ID: 3, Name: "Bob", Email: "bob@corp.com"})
+	cache.Set(models.User{ID: 1, Name: "John", Email: "john@corp.com"})
+	consistent(t)
+	assert.Empty(t, cache.LookupIndex("domain", "example.com"), "a moved item leaves its old bucket")
+
+	cache.Patch(2, func(u *models.User) { u.Email = "jane@example.org" })
+	cache.Delete(3)
+	cache.DeleteMany([]uint{9})
+	consistent(t)
+	assert.Len(t, cache.LookupIndex("domain", "corp.com"), 1)
+
+	assert.NoError(t, cache.Refresh())
+	consistent(t)
+	assert.Len(t, cache.LookupIndex("domain", "example.com"), 1)
+	assert.Nil(t, cache.LookupIndex("missing", "example.com"))
+}
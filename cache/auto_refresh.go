@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Logger receives errors from background work such as auto-refresh. It is
+// satisfied by *log.Logger.
+type Logger interface {
+	Printf(format string, args ...any)
+}
+
+// WithLogger sets where background refresh errors are logged. Without a
+// logger they are only kept in RecentErrors.
+func (cm *CacheManager[T]) WithLogger(logger Logger) *CacheManager[T] {
+	cm.logger = logger
+	return cm
+}
+
+// StartAutoRefresh spawns a goroutine that calls Refresh every interval,
+// so the cache stays warm instead of reloading on the read path after the
+// TTL lapses. The goroutine stops when ctx is done, when the returned stop
+// function is called, which waits for a running refresh to finish, or when
+// the cache is closed. Only one auto-refresh may run at a time; a second
+// call fails with ErrAutoRefreshRunning until the first one stops, and a
+// closed cache fails with ErrCacheClosed.
+func (cm *CacheManager[T]) StartAutoRefresh(ctx context.Context, interval time.Duration) (stop func(), err error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid auto refresh interval %s", interval)
+	}
+	cm.autoMu.Lock()
+	defer cm.autoMu.Unlock()
+	if cm.closed.Load() {
+		return nil, ErrCacheClosed
+	}
+	if !cm.autoRefresh.CompareAndSwap(false, true) {
+		return nil, ErrAutoRefreshRunning
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer cm.autoRefresh.Store(false)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			err := cm.Refresh()
+			if errors.Is(err, ErrCacheClosed) {
+				return
+			}
+			if err != nil && cm.logger != nil {
+				cm.logf("cache auto refresh failed: %v", err)
+			}
+		}
+	}()
+
+	cm.autoStop = func() {
+		cancel()
+		<-done
+	}
+	return cm.autoStop, nil
+}
+
+// stopAutoRefresh stops a running StartAutoRefresh goroutine and waits for
+// it to exit
+func (cm *CacheManager[T]) stopAutoRefresh() {
+	cm.autoMu.Lock()
+	stop := cm.autoStop
+	cm.autoStop = nil
+	cm.autoMu.Unlock()
+	if stop != nil {
+		stop()
+	}
+}
@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingUserLoader counts loads and fails while err is set
+type countingUserLoader struct {
+	loads atomic.Int32
+	mu    sync.Mutex
+	err   error
+}
+
+func (l *countingUserLoader) Load() ([]models.User, error) {
+	l.loads.Add(1)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return []models.User{{ID: 1, Name: "John"}}, l.err
+}
+
+func (l *countingUserLoader) fail(err error) {
+	l.mu.Lock()
+	l.err = err
+	l.mu.Unlock()
+}
+
+// recordingLogger collects logged messages
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) Printf(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.messages)
+}
+
+func TestCacheManagerStartAutoRefresh(t *testing.T) {
+	loader := &countingUserLoader{}
+	cache := NewCacheManager[models.User](loader)
+
+	stop, err := cache.StartAutoRefresh(context.Background(), 5*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return loader.loads.Load() >= 2 }, time.Second, time.Millisecond)
+	_, err = cache.Get(1)
+	assert.NoError(t, err)
+
+	_, err = cache.StartAutoRefresh(context.Background(), 5*time.Millisecond)
+	assert.ErrorIs(t, err, ErrAutoRefreshRunning)
+
+	stop()
+	loads := loader.loads.Load()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, loads, loader.loads.Load(), "no refreshes after stop")
+	stop()
+
+	t.Run("can restart after stop", func(t *testing.T) {
+		stop, err := cache.StartAutoRefresh(context.Background(), 5*time.Millisecond)
+		assert.NoError(t, err)
+		defer stop()
+		assert.Eventually(t, func() bool { return loader.loads.Load() > loads }, time.Second, time.Millisecond)
+	})
+
+	t.Run("rejects a non-positive interval", func(t *testing.T) {
+		_, err := cache.StartAutoRefresh(context.Background(), 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestCacheManagerAutoRefreshErrors(t *testing.T) {
+	loader := &countingUserLoader{}
+	loader.fail(errors.New("database down"))
+	logger := &recordingLogger{}
+	cache := NewCacheManager[models.User](loader).WithLogger(logger)
+
+	stop, err := cache.StartAutoRefresh(context.Background(), 5*time.Millisecond)
+	assert.NoError(t, err)
+	defer stop()
+
+	assert.Eventually(t, func() bool { return logger.count() >= 2 }, time.Second, time.Millisecond)
+	logger.mu.Lock()
+	assert.Contains(t, logger.messages[0], "database down")
+	logger.mu.Unlock()
+	assert.NotEmpty(t, cache.RecentErrors())
+}
+
+func TestCacheManagerAutoRefreshStops(t *testing.T) {
+	t.Run("when the context is done", func(t *testing.T) {
+		cache := NewCacheManager[models.User](&countingUserLoader{})
+		ctx, cancel := context.WithCancel(context.Background())
+		_, err := cache.StartAutoRefresh(ctx, 5*time.Millisecond)
+		assert.NoError(t, err)
+
+		cancel()
+		assert.Eventually(t, func() bool { return !cache.autoRefresh.Load() }, time.Second, time.Millisecond)
+	})
+
+	t.Run("when the cache is closed", func(t *testing.T) {
+		cache := NewCacheManager[models.User](&countingUserLoader{})
+		_, err := cache.StartAutoRefresh(context.Background(), 5*time.Millisecond)
+		assert.NoError(t, err)
+
+		cache.Close()
+		assert.False(t, cache.autoRefresh.Load(), "Close waits for the goroutine to exit")
+
+		_, err = cache.StartAutoRefresh(context.Background(), 5*time.Millisecond)
+		assert.ErrorIs(t, err, ErrCacheClosed)
+	})
+
+	t.Run("when drained with a long interval", func(t *testing.T) {
+		cache := NewCacheManager[models.User](&countingUserLoader{})
+		_, err := cache.StartAutoRefresh(context.Background(), time.Hour)
+		assert.NoError(t, err)
+
+		var buf bytes.Buffer
+		assert.NoError(t, cache.DrainAndClose(&buf))
+		assert.False(t, cache.autoRefresh.Load(), "the goroutine does not idle until the next tick")
+	})
+}
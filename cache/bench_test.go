@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func syntheticUser(id uint) models.User {
+	return models.User{
+		ID:    id,
+		Name:  fmt.Sprintf("user-%d", id),
+		Email: fmt.Sprintf("user-%d@example%d.com", id, id%10),
+	}
+}
+
+var benchSizes = []int{10_000, 100_000}
+
+func BenchmarkGetHit(b *testing.B) {
+	cache := NewSyntheticCacheManager(10_000, syntheticUser)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = cache.Get(uint(i%10_000 + 1))
+	}
+}
+
+func BenchmarkGetMiss(b *testing.B) {
+	cache := NewSyntheticCacheManager(10_000, syntheticUser)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = cache.Get(uint(i%10_000 + 20_000))
+	}
+}
+
+func BenchmarkQueryString(b *testing.B) {
+	condition := StringFieldCondition[models.User]{
+		FieldExtractor: func(u models.User) string { return u.Email },
+		Value:          "@example3.com",
+		Operation:      "endsWith",
+	}
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			cache := NewSyntheticCacheManager(n, syntheticUser)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				cache.Query(condition)
+			}
+		})
+	}
+}
+
+func BenchmarkQueryComposite(b *testing.B) {
+	condition := And[models.User](
+		StringFieldCondition[models.User]{
+			FieldExtractor: func(u models.User) string { return u.Name },
+			Value:          "user-1",
+			Operation:      "startsWith",
+		},
+		NumberFieldCondition[models.User, uint]{
+			FieldExtractor: func(u models.User) uint { return u.ID },
+			Value:          5_000,
+			Operation:      "gt",
+		},
+	)
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			cache := NewSyntheticCacheManager(n, syntheticUser)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				cache.Query(condition)
+			}
+		})
+	}
+}
+
+func BenchmarkRefresh(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			cache := NewSyntheticCacheManager(n, syntheticUser)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := cache.Refresh(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestNewSyntheticCacheManager(t *testing.T) {
+	cache := NewSyntheticCacheManager(100, syntheticUser)
+	user, err := cache.Get(42)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-42", user.Name)
+	assert.Len(t, cache.GetAll(), 100)
+	assert.NoError(t, cache.Refresh())
+	assert.Len(t, cache.GetAll(), 100)
+}
@@ -1,27 +1,100 @@
 package cache
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"iter"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unsafe"
+)
+
+// ExpiryPolicy controls what reads return once the cache has expired
+type ExpiryPolicy string
+
+const (
+	// ExpiryError makes Get return an error and list reads return nil
+	ExpiryError ExpiryPolicy = "error"
+	// ExpiryServeStale keeps serving the expired data until the next refresh
+	ExpiryServeStale ExpiryPolicy = "serve-stale"
+	// ExpiryEmpty makes reads behave as if the cache held no items
+	ExpiryEmpty ExpiryPolicy = "empty"
 )
 
 // CacheManager implements the Cache interface using a thread-safe map
 type CacheManager[T Identifiable] struct {
-	data      map[uint]T
-	mu        sync.RWMutex
-	loader    DataLoader[T]
-	ttl       time.Duration
-	lastFetch time.Time
+	data        map[uint]T
+	meta        map[uint]*entryMeta
+	trackAccess bool
+	mu          sync.RWMutex
+	loader      DataLoader[T]
+	ttl         time.Duration
+	lastFetch   time.Time
+	getPolicy   ExpiryPolicy
+	listPolicy  ExpiryPolicy
+	expiryFunc  func() bool
+	permanent   bool        // never expires, see WithPermanent
+	signaled    atomic.Bool // expiryFunc fired since the last refresh
+	stats       cacheStats
+	adaptive    *adaptiveRefresh
+	codec       Codec
+	closed      atomic.Bool
+	autoRefresh atomic.Bool // a StartAutoRefresh goroutine is running
+	autoMu      sync.Mutex
+	autoStop    func() // stops the StartAutoRefresh goroutine, see Close
+	logger      Logger
+	lastErr     error // error of the last failed load, cleared by a successful one
+	errorLog    errorRing
+	onRefresh   []func(added, removed, changed int)
+	onChange    []func(added, updated, removed []T)
+	onExpire    []func()
+	equals      func(a, b T) bool
+	less        func(a, b T) bool // result order, see WithDeterministicOrder
+	batch       *batcher[T]
+	indexFuncs  map[string]func(T) string
+	indexFields map[string]uintptr                      // code pointer of each index func, see stringCandidates
+	indexes     map[string]map[string]map[uint]struct{} // index -> key -> IDs
+	attached    map[string]Index[T]                     // see WithIndex
+
+	lru          *lruTracker // set when the size is bounded
+	memoryBudget int64
+	maxEntries   int
+	sizeBytes    int64             // estimated size, tracked under a memory budget
+	priorities   map[uint]int      // eviction priority by ID, see SetWithPriority
+	pinned       map[uint]struct{} // IDs exempt from eviction, see Pin
+
+	expireObserved atomic.Bool // a read saw the current expiry
+	expirePending  atomic.Bool // OnExpire hooks are due for it
+
+	revalidate   bool        // refresh in the background on expiry
+	revalidating atomic.Bool // a background revalidation is running
+
+	flight refreshFlight // deduplicates concurrent Refresh calls
+	events eventBus
+
+	recoverPanics bool                  // see WithPanicRecovery
+	expiryPanic   atomic.Pointer[error] // recovered from expiryFunc, see notifyExpire
+	name          string                // see WithName
+
+	lazy        bool               // load Get misses, see WithLazyLoad
+	negativeTTL time.Duration      // how long not found IDs are remembered
+	negative    map[uint]time.Time // not found IDs and when they were looked up
 }
 
-// NewCacheManager creates a new cache manager instance with a default TTL of permanent if not set
+// NewCacheManager creates a new cache manager instance with a default TTL of permanent if not set.
+// A nil loader creates a push-only cache, filled through Set, SetAll and Apply.
 func NewCacheManager[T Identifiable](loader DataLoader[T]) *CacheManager[T] {
 	return &CacheManager[T]{
-		data:      make(map[uint]T),
-		loader:    loader,
-		ttl:       0, // Default to permanent
-		lastFetch: time.Time{},
+		data:       make(map[uint]T),
+		meta:       make(map[uint]*entryMeta),
+		loader:     loader,
+		ttl:        0, // Default to permanent
+		lastFetch:  time.Time{},
+		getPolicy:  ExpiryError,
+		listPolicy: ExpiryError,
+		errorLog:   newErrorRing(defaultErrorLogSize),
 	}
 }
 
@@ -31,8 +104,54 @@ func (cm *CacheManager[T]) WithTTL(ttl time.Duration) *CacheManager[T] {
 	return cm
 }
 
+// WithPermanent marks the cache as holding static reference data that
+// never expires: reads skip the expiry check entirely, ignoring the TTL and
+// any WithExpiryFunc predicate, and IsStale always reports false. Refresh
+// still reloads the data when called explicitly.
+func (cm *CacheManager[T]) WithPermanent() *CacheManager[T] {
+	cm.permanent = true
+	cm.ttl = 0
+	return cm
+}
+
+// WithExpiryPolicy sets how an expired cache answers Get (getPolicy) and
+// GetAll, Keys and Query (listPolicy). Both default to ExpiryError, which
+// makes Get fail and list reads return nil.
+func (cm *CacheManager[T]) WithExpiryPolicy(getPolicy, listPolicy ExpiryPolicy) *CacheManager[T] {
+	cm.getPolicy = getPolicy
+	cm.listPolicy = listPolicy
+	return cm
+}
+
+// WithExpiryFunc adds a predicate consulted alongside the TTL, e.g. to expire
+// the cache on a version bump. Once it returns true, reads treat the cache as
+// expired until the next Refresh, even if the predicate flips back.
+func (cm *CacheManager[T]) WithExpiryFunc(expiryFunc func() bool) *CacheManager[T] {
+	cm.expiryFunc = expiryFunc
+	return cm
+}
+
+// OnExpire registers a hook called once per expiry, when a read first
+// observes that the cache has expired. The next refresh arms it again.
+// Hooks run after the lock is released, so they may call back into the
+// cache, e.g. to trigger a Refresh.
+func (cm *CacheManager[T]) OnExpire(hook func()) *CacheManager[T] {
+	cm.onExpire = append(cm.onExpire, hook)
+	return cm
+}
+
+// SetLoader replaces the loader used by subsequent Refresh calls.
+// The cached data is kept until the next refresh.
+func (cm *CacheManager[T]) SetLoader(loader DataLoader[T]) {
+	cm.executeWithLock(false, func() interface{} {
+		cm.loader = loader
+		return nil
+	})
+}
+
 // Template method pattern for cache operations
 func (cm *CacheManager[T]) executeWithLock(read bool, operation func() interface{}) interface{} {
+	defer cm.notifyExpire()
 	if read {
 		cm.mu.RLock()
 		defer cm.mu.RUnlock()
@@ -43,15 +162,31 @@ func (cm *CacheManager[T]) executeWithLock(read bool, operation func() interface
 	return operation()
 }
 
-// Get retrieves an item by ID
+// Get retrieves an item by ID. With WithLazyLoad a miss loads the item. An
+// entry loaded by GetOrLoad is served within its own TTL even after the
+// cache expired.
 func (cm *CacheManager[T]) Get(id uint) (T, error) {
+	if cm.lazy {
+		return cm.getLazy(id)
+	}
 	result := cm.executeWithLock(true, func() interface{} {
-		if cm.isExpired() {
+		if cm.notInitialized() {
+			var zero T
+			return struct {
+				item T
+				err  error
+			}{zero, cm.named(ErrCacheNotInitialized)}
+		}
+		if cm.isExpired() && cm.getPolicy != ExpiryServeStale && !cm.entryFresh(id) {
 			var zero T
+			err := cm.named(ErrCacheExpired)
+			if cm.getPolicy == ExpiryEmpty {
+				err = cm.named(fmt.Errorf("item with ID %d not found", id))
+			}
 			return struct {
 				item T
 				err  error
-			}{zero, fmt.Errorf("cache expired")}
+			}{zero, err}
 		}
 		item, exists := cm.data[id]
 		if !exists {
@@ -59,8 +194,9 @@ func (cm *CacheManager[T]) Get(id uint) (T, error) {
 			return struct {
 				item T
 				err  error
-			}{zero, fmt.Errorf("item with ID %d not found", id)}
+			}{zero, cm.named(fmt.Errorf("item with ID %d not found", id))}
 		}
+		cm.recordAccess(id)
 		return struct {
 			item T
 			err  error
@@ -70,14 +206,47 @@ func (cm *CacheManager[T]) Get(id uint) (T, error) {
 		item T
 		err  error
 	})
+	cm.stats.record(res.err == nil)
 	return res.item, res.err
 }
 
+// GetUnchecked retrieves an item by ID without checking expiry. It may
+// return stale data and is meant for callers that manage freshness
+// themselves, e.g. by calling IsStale once before a batch of reads.
+func (cm *CacheManager[T]) GetUnchecked(id uint) (T, bool) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	item, exists := cm.data[id]
+	return item, exists
+}
+
+// IsStale reports whether the cache has outlived its TTL
+func (cm *CacheManager[T]) IsStale() bool {
+	defer cm.notifyExpire()
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.isExpired()
+}
+
+// Has reports whether an item with the given ID is cached, treating an
+// expired cache the same way Get does
+func (cm *CacheManager[T]) Has(id uint) bool {
+	defer cm.notifyExpire()
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.notInitialized() || (cm.isExpired() && cm.getPolicy != ExpiryServeStale) {
+		return false
+	}
+	_, exists := cm.data[id]
+	return exists
+}
+
 // GetAll returns all items in the cache
 func (cm *CacheManager[T]) GetAll() []T {
 	result := cm.executeWithLock(true, func() interface{} {
-		if cm.isExpired() {
-			return nil
+		if !cm.listAllowed() {
+			return unavailableList[T](cm)
 		}
 		items := make([]T, 0, len(cm.data))
 		for _, item := range cm.data {
@@ -85,52 +254,621 @@ func (cm *CacheManager[T]) GetAll() []T {
 		}
 		return items
 	})
-	return result.([]T)
+	return cm.sort(result.([]T))
 }
 
-// Refresh reloads the cache data
+// All returns an iterator over the cached ID/item pairs. The items are
+// copied under the read lock when iteration starts and yielded without
+// holding it, so the loop body may call back into the cache.
+func (cm *CacheManager[T]) All() iter.Seq2[uint, T] {
+	return func(yield func(uint, T) bool) {
+		result := cm.executeWithLock(true, func() interface{} {
+			if !cm.listAllowed() {
+				return map[uint]T(nil)
+			}
+			snapshot := make(map[uint]T, len(cm.data))
+			for id, item := range cm.data {
+				snapshot[id] = item
+			}
+			return snapshot
+		})
+		for id, item := range result.(map[uint]T) {
+			if !yield(id, item) {
+				return
+			}
+		}
+	}
+}
+
+// Matching returns an iterator over the cached items that match the
+// condition. Like All, it iterates a snapshot taken when iteration starts.
+func (cm *CacheManager[T]) Matching(condition QueryCondition[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		guarded, report := cm.guardCondition(condition)
+		defer report()
+		for _, item := range cm.All() {
+			if guarded.Match(item) && !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns the IDs of all items in the cache. The order is
+// nondeterministic; callers that need a stable order must sort the result.
+func (cm *CacheManager[T]) Keys() []uint {
+	result := cm.executeWithLock(true, func() interface{} {
+		if !cm.listAllowed() {
+			return unavailableList[uint](cm)
+		}
+		keys := make([]uint, 0, len(cm.data))
+		for id := range cm.data {
+			keys = append(keys, id)
+		}
+		return keys
+	})
+	return result.([]uint)
+}
+
+// Refresh reloads the cache data. Concurrent calls are deduplicated: while
+// a refresh is in flight, further callers wait for it and share its result
+// instead of loading again, and OnRefresh hooks fire once.
 func (cm *CacheManager[T]) Refresh() error {
+	var result refreshChanges[T]
+	// The lock is taken directly rather than through executeWithLock so
+	// that expiry hooks, which may call Refresh, run outside the flight.
+	leader, err := cm.flight.do(func() error {
+		cm.mu.Lock()
+		defer cm.mu.Unlock()
+		if cm.closed.Load() {
+			return ErrCacheClosed
+		}
+		if cm.loader == nil {
+			return ErrNoLoader
+		}
+		items, err := cm.load(cm.loader.Load)
+		if err != nil {
+			cm.recordError(err)
+			return err
+		}
+		result = cm.swapData(items, time.Now(), false)
+		return nil
+	})
+	cm.notifyExpire()
+	if err != nil {
+		return cm.named(err)
+	}
+	if leader {
+		cm.fireRefresh(result)
+	}
+	return nil
+}
+
+// RefreshContext reloads the cache data like Refresh but gives up once ctx
+// is done. The load runs without holding the lock, so reads keep being
+// served meanwhile. Loaders implementing ContextLoader get ctx, so their
+// queries are cancelled with it; any other loader keeps running after a
+// timeout and its result is discarded. On a deadline the error reports
+// how long the load ran, to help tune timeouts.
+func (cm *CacheManager[T]) RefreshContext(ctx context.Context) error {
+	result, err := cm.loadAndSwap(ctx)
+	if err != nil {
+		return err
+	}
+	cm.fireRefresh(result)
+	return nil
+}
+
+// loadAndSwap implements RefreshContext up to the OnRefresh and change
+// hooks, which the caller fires with the returned changes
+func (cm *CacheManager[T]) loadAndSwap(ctx context.Context) (refreshChanges[T], error) {
+	if err := ctx.Err(); err != nil {
+		return refreshChanges[T]{}, err
+	}
+	if cm.closed.Load() {
+		return refreshChanges[T]{}, ErrCacheClosed
+	}
+
+	cm.mu.RLock()
+	loader := cm.loader
+	cm.mu.RUnlock()
+	if loader == nil {
+		return refreshChanges[T]{}, ErrNoLoader
+	}
+
+	type loadResult struct {
+		items []T
+		err   error
+	}
+	started := time.Now()
+	done := make(chan loadResult, 1)
+	go func() {
+		if cm.recoverPanics {
+			defer func() {
+				if r := recover(); r != nil {
+					done <- loadResult{err: panicError("loader", r)}
+				}
+			}()
+		}
+		var items []T
+		var err error
+		if cl, ok := loader.(ContextLoader[T]); ok {
+			items, err = cl.LoadCtx(ctx)
+		} else {
+			items, err = loader.Load()
+		}
+		done <- loadResult{items: items, err: err}
+	}()
+
+	var res loadResult
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res.err = ctx.Err()
+	}
+
+	if res.err != nil {
+		if errors.Is(res.err, context.DeadlineExceeded) {
+			res.err = fmt.Errorf("refresh timed out after %s: %w", time.Since(started).Round(time.Millisecond), res.err)
+		}
+		cm.mu.Lock()
+		cm.recordError(res.err)
+		cm.mu.Unlock()
+		return refreshChanges[T]{}, cm.named(res.err)
+	}
+	return cm.swapLoaded(res.items)
+}
+
+// swapLoaded installs loaded items under the write lock unless the cache
+// was closed meanwhile. The unlock is deferred since swapData runs user
+// callbacks such as the equality and index funcs.
+func (cm *CacheManager[T]) swapLoaded(items []T) (refreshChanges[T], error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if cm.closed.Load() {
+		return refreshChanges[T]{}, ErrCacheClosed
+	}
+	return cm.swapData(items, time.Now(), false), nil
+}
+
+// RefreshIncremental loads only the items whose updatedAtColumn is newer
+// than the last fetch and merges them into the cache by ID. Deletions in the
+// source are not detected, so pair it with a periodic full Refresh. The
+// loader must implement IncrementalLoader; a cache that was never refreshed
+// falls back to a full Refresh.
+func (cm *CacheManager[T]) RefreshIncremental(updatedAtColumn string) error {
 	err := cm.executeWithLock(false, func() interface{} {
-		items, err := cm.loader.Load()
+		if cm.closed.Load() {
+			return ErrCacheClosed
+		}
+		if cm.loader == nil {
+			return ErrNoLoader
+		}
+		loader, ok := cm.loader.(IncrementalLoader[T])
+		if !ok {
+			return fmt.Errorf("loader does not support incremental refresh")
+		}
+
+		// Use the time the load started as the next watermark so rows
+		// updated while it runs are picked up by the following refresh
+		started := time.Now()
+		if cm.lastFetch.IsZero() {
+			items, err := cm.load(loader.Load)
+			if err != nil {
+				cm.recordError(err)
+				return err
+			}
+			cm.setData(items, started)
+			cm.markFetched(started)
+			cm.events.emit(Event{Kind: EventInit})
+			cm.events.emit(Event{Kind: EventRefresh})
+			return nil
+		}
+
+		items, err := cm.load(func() ([]T, error) { return loader.LoadSince(updatedAtColumn, cm.lastFetch) })
 		if err != nil {
+			cm.recordError(err)
 			return err
 		}
-		newData := make(map[uint]T)
 		for _, item := range items {
-			newData[item.GetID()] = item
+			cm.putItem(item, started)
 		}
-		cm.data = newData
-		cm.lastFetch = time.Now()
+		cm.markFetched(started)
+		cm.events.emit(Event{Kind: EventRefresh})
 		return nil
 	})
 	if err != nil {
-		return err.(error)
+		return cm.named(err.(error))
 	}
 	return nil
 }
 
+// VerifyResult describes how the cache differs from its source.
+// IDs are sorted in ascending order.
+type VerifyResult struct {
+	OnlyInCache  []uint // cached items missing from the source
+	OnlyInSource []uint // source items missing from the cache
+	Different    []uint // items whose cached value differs from the source
+}
+
+// InSync reports whether the cache matches the source
+func (r VerifyResult) InSync() bool {
+	return len(r.OnlyInCache) == 0 && len(r.OnlyInSource) == 0 && len(r.Different) == 0
+}
+
+// Verify loads fresh data from the loader and compares it with the cache
+// without replacing the cached data. The context is checked before and
+// after the load.
+func (cm *CacheManager[T]) Verify(ctx context.Context) (VerifyResult, error) {
+	if err := ctx.Err(); err != nil {
+		return VerifyResult{}, err
+	}
+
+	cm.mu.RLock()
+	loader := cm.loader
+	cm.mu.RUnlock()
+	if loader == nil {
+		return VerifyResult{}, ErrNoLoader
+	}
+
+	var items []T
+	var err error
+	if perr := cm.recoverLoad(func() { items, err = loader.Load() }); perr != nil {
+		return VerifyResult{}, perr
+	}
+	if err != nil {
+		return VerifyResult{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return VerifyResult{}, err
+	}
+
+	source := make(map[uint]T, len(items))
+	for _, item := range items {
+		source[item.GetID()] = item
+	}
+
+	g, report := cm.guard()
+	defer report()
+	d := cm.executeWithLock(true, func() interface{} {
+		return diffData(cm.data, source, cm.guardedEquals(g))
+	}).(dataDiff)
+
+	return VerifyResult{
+		OnlyInCache:  d.removed,
+		OnlyInSource: d.added,
+		Different:    d.updated,
+	}, nil
+}
+
+// Patch atomically updates a cached item in place: under the write lock it
+// calls mutate on a copy of the item, stores the result and returns it. The
+// second result is false, and mutate is not called, if the item is not
+// cached. It is also false, leaving the item unchanged, if mutate panics
+// under WithPanicRecovery. mutate must not call back into the cache.
+func (cm *CacheManager[T]) Patch(id uint, mutate func(*T)) (T, bool) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	item, exists := cm.data[id]
+	if !exists {
+		var zero T
+		return zero, false
+	}
+	if !cm.callLocked("Patch mutate", func() { mutate(&item) }) {
+		var zero T
+		return zero, false
+	}
+	cm.putItem(item, time.Now())
+	return item, true
+}
+
+// Set inserts or overwrites a single item, e.g. after writing it to the
+// database, without reloading the whole dataset. It does not count as a
+// refresh, so the TTL keeps running from the last one; use SetAndTouch to
+// restart it as well.
+func (cm *CacheManager[T]) Set(item T) {
+	cm.executeWithLock(false, func() interface{} {
+		cm.putItem(item, time.Now())
+		return nil
+	})
+}
+
+// SetAll inserts or overwrites several items under a single write lock.
+// Unlike Apply it keeps the other cached items, and like Set it leaves the
+// TTL untouched.
+func (cm *CacheManager[T]) SetAll(items []T) {
+	cm.executeWithLock(false, func() interface{} {
+		now := time.Now()
+		for _, item := range items {
+			cm.putItem(item, now)
+		}
+		return nil
+	})
+}
+
+// SetAndTouch inserts or overwrites a single item like Set and restarts the
+// TTL as if the cache had just been refreshed
+func (cm *CacheManager[T]) SetAndTouch(item T) {
+	cm.executeWithLock(false, func() interface{} {
+		now := time.Now()
+		cm.putItem(item, now)
+		cm.markFetched(now)
+		return nil
+	})
+}
+
+// Delete removes a single item from the cache. Like Set it leaves the TTL
+// untouched.
+func (cm *CacheManager[T]) Delete(id uint) {
+	cm.executeWithLock(false, func() interface{} {
+		cm.removeItem(id)
+		return nil
+	})
+}
+
+// DeleteMany removes the given items under a single write lock, e.g. for a
+// batch of tombstones, and returns how many were cached. Like Delete it
+// leaves the TTL untouched.
+func (cm *CacheManager[T]) DeleteMany(ids []uint) int {
+	result := cm.executeWithLock(false, func() interface{} {
+		removed := 0
+		for _, id := range ids {
+			if _, exists := cm.data[id]; exists {
+				cm.removeItem(id)
+				removed++
+			}
+		}
+		return removed
+	})
+	return result.(int)
+}
+
 // Clear removes all items from the cache
 func (cm *CacheManager[T]) Clear() {
 	cm.executeWithLock(false, func() interface{} {
-		cm.data = make(map[uint]T)
+		cm.clearData(0)
+		cm.events.emit(Event{Kind: EventClear})
 		return nil
 	})
 }
 
+// Reset returns the cache to its freshly constructed state: no data, no
+// fetch time (so reads report ErrCacheNotInitialized), zeroed stats and no
+// recorded errors. Configuration such as the loader, TTL, policies and hooks
+// is kept. Unlike Clear, which only drops the items, a reset cache must be
+// refreshed before it serves reads again.
+func (cm *CacheManager[T]) Reset() {
+	cm.executeWithLock(false, func() interface{} {
+		cm.clearData(0)
+		cm.lastFetch = time.Time{}
+		cm.signaled.Store(false)
+		cm.lastErr = nil
+		cm.errorLog = newErrorRing(len(cm.errorLog.entries))
+		cm.stats.reset()
+		cm.events.emit(Event{Kind: EventClear})
+		if cm.adaptive != nil {
+			cm.adaptive.reset()
+		}
+		return nil
+	})
+}
+
+// AsRelated builds a RelatedCacheManager from the cache's current data,
+// indexed by the foreign key fkExtractor returns. It shares the loader and
+// TTL and keeps the fetch time, so the related manager serves lookups
+// immediately without a Refresh.
+func (cm *CacheManager[T]) AsRelated(fkExtractor func(T) uint) *RelatedCacheManager[T] {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	rcm := &RelatedCacheManager[T]{
+		data:      make(map[uint]T, len(cm.data)),
+		fkIndex:   make(map[uint][]uint),
+		fkFunc:    fkExtractor,
+		loader:    cm.loader,
+		ttl:       cm.ttl,
+		lastFetch: cm.lastFetch,
+	}
+	for pk, item := range cm.data {
+		fk := fkExtractor(item)
+		rcm.data[pk] = item
+		rcm.fkIndex[fk] = append(rcm.fkIndex[fk], pk)
+	}
+	return rcm
+}
+
+// MergePolicy decides which item wins when a merge finds the same ID in both caches
+type MergePolicy int
+
+const (
+	// MergeKeepExisting keeps the receiver's item
+	MergeKeepExisting MergePolicy = iota
+	// MergeOverwrite replaces the receiver's item with the other cache's
+	MergeOverwrite
+)
+
+// Merge copies other's items into the cache, resolving duplicate IDs with
+// the policy. Both caches are locked for the duration, always in the same
+// order, so concurrent merges in opposite directions cannot deadlock. The
+// merged cache is considered as old as the older of the two.
+func (cm *CacheManager[T]) Merge(other *CacheManager[T], policy MergePolicy) {
+	if other == nil || other == cm {
+		return
+	}
+
+	first, second := &cm.mu, &other.mu
+	if uintptr(unsafe.Pointer(cm)) > uintptr(unsafe.Pointer(other)) {
+		first, second = second, first
+	}
+	first.Lock()
+	defer first.Unlock()
+	second.Lock()
+	defer second.Unlock()
+
+	for id, item := range other.data {
+		if _, exists := cm.data[id]; exists && policy == MergeKeepExisting {
+			continue
+		}
+		loadedAt := other.lastFetch
+		if m := other.meta[id]; m != nil {
+			loadedAt = m.loadedAt
+		}
+		cm.putItem(item, loadedAt)
+	}
+	if cm.lastFetch.IsZero() || (!other.lastFetch.IsZero() && other.lastFetch.Before(cm.lastFetch)) {
+		cm.lastFetch = other.lastFetch
+	}
+}
+
+// Compact rebuilds the data map at its current size, releasing the backing
+// storage a map keeps after many deletions. It is O(n) and holds the write
+// lock throughout, so call it during low-traffic windows.
+func (cm *CacheManager[T]) Compact() {
+	cm.executeWithLock(false, func() interface{} {
+		compacted := make(map[uint]T, len(cm.data))
+		meta := make(map[uint]*entryMeta, len(cm.data))
+		for id, item := range cm.data {
+			compacted[id] = item
+			if m := cm.meta[id]; m != nil {
+				meta[id] = m
+			}
+		}
+		cm.data = compacted
+		cm.meta = meta
+		cm.rebuildIndexes()
+		return nil
+	})
+}
+
+// isExpired reports whether the cache has expired and records the first
+// observation of each expiry for the OnExpire hooks
 func (cm *CacheManager[T]) isExpired() bool {
-	return cm.ttl > 0 && !cm.lastFetch.IsZero() && time.Since(cm.lastFetch) > cm.ttl
+	if !cm.expired() {
+		return false
+	}
+	if cm.revalidate {
+		cm.startRevalidate()
+	}
+	if len(cm.onExpire) > 0 && !cm.lastFetch.IsZero() && cm.expireObserved.CompareAndSwap(false, true) {
+		cm.expirePending.Store(true)
+	}
+	return true
+}
+
+func (cm *CacheManager[T]) expired() bool {
+	if cm.permanent {
+		return false
+	}
+	if cm.ttl > 0 && !cm.lastFetch.IsZero() && time.Since(cm.lastFetch) > cm.ttl {
+		return true
+	}
+	if cm.signaled.Load() {
+		return true
+	}
+	if cm.expiryFunc != nil && cm.expiryFired() {
+		cm.signaled.Store(true)
+		return true
+	}
+	return false
 }
 
-// Query returns items that match the given condition
+// notifyExpire runs the OnExpire hooks for a pending expiry and reports a
+// panic recovered from the expiry predicate. It must be called without the
+// lock.
+func (cm *CacheManager[T]) notifyExpire() {
+	if err := cm.expiryPanic.Swap(nil); err != nil {
+		cm.reportPanic(*err)
+	}
+	if cm.expirePending.CompareAndSwap(true, false) {
+		for _, hook := range cm.onExpire {
+			cm.safeCall("OnExpire hook", hook)
+		}
+	}
+}
+
+// markFetched records a completed refresh
+func (cm *CacheManager[T]) markFetched(at time.Time) {
+	cm.lastFetch = at
+	cm.signaled.Store(false)
+	cm.expireObserved.Store(false)
+	cm.lastErr = nil
+}
+
+// notInitialized reports whether the cache still waits for its first
+// refresh. A cache without a loader is filled by pushes and never does.
+func (cm *CacheManager[T]) notInitialized() bool {
+	return cm.loader != nil && cm.lastFetch.IsZero()
+}
+
+// listAllowed reports whether list reads may serve the cached data
+func (cm *CacheManager[T]) listAllowed() bool {
+	return !cm.notInitialized() && (!cm.isExpired() || cm.listPolicy == ExpiryServeStale)
+}
+
+// unavailableList returns the result of a list read that may not serve the
+// cached data: nil, or an empty slice under the ExpiryEmpty policy
+func unavailableList[E any, T Identifiable](cm *CacheManager[T]) []E {
+	if !cm.notInitialized() && cm.listPolicy == ExpiryEmpty {
+		return []E{}
+	}
+	return nil
+}
+
+// Query returns items that match the given condition. A condition naming an
+// indexed field, see StringFieldCondition.FieldName, is answered from the
+// index.
 func (cm *CacheManager[T]) Query(condition QueryCondition[T]) []T {
+	return cm.sort(cm.query(condition))
+}
+
+// query returns the items matching the condition in no particular order
+func (cm *CacheManager[T]) query(condition QueryCondition[T]) []T {
+	guarded, report := cm.guardCondition(condition)
+	defer report()
 	result := cm.executeWithLock(true, func() interface{} {
-		if cm.isExpired() {
-			return nil
+		if !cm.listAllowed() {
+			return unavailableList[T](cm)
 		}
 		result := make([]T, 0)
+		if ids, ok := cm.indexCandidates(condition); ok {
+			for id := range ids {
+				if item := cm.data[id]; guarded.Match(item) {
+					result = append(result, item)
+				}
+			}
+			return result
+		}
+		for _, item := range cm.data {
+			if guarded.Match(item) {
+				result = append(result, item)
+			}
+		}
+		return result
+	})
+	return result.([]T)
+}
+
+// QueryLimit returns up to k items matching the condition, stopping the scan
+// as soon as k are found. Map iteration order is random, so which k items are
+// returned is arbitrary; use it when any k matches will do.
+func (cm *CacheManager[T]) QueryLimit(condition QueryCondition[T], k int) []T {
+	guarded, report := cm.guardCondition(condition)
+	defer report()
+	result := cm.executeWithLock(true, func() interface{} {
+		if !cm.listAllowed() {
+			return unavailableList[T](cm)
+		}
+		result := make([]T, 0, max(0, min(k, len(cm.data))))
+		if k <= 0 {
+			return result
+		}
 		for _, item := range cm.data {
-			if condition.Match(item) {
+			if guarded.Match(item) {
 				result = append(result, item)
+				if len(result) == k {
+					break
+				}
 			}
 		}
 		return result
@@ -1,6 +1,12 @@
 package cache
 
 import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -46,10 +52,20 @@ func TestCacheManager(t *testing.T) {
 		assert.Error(t, err)
 	})
 
+	t.Run("Has reports cached IDs", func(t *testing.T) {
+		assert.True(t, cache.Has(1))
+		assert.False(t, cache.Has(999))
+	})
+
+	t.Run("Keys returns all cached IDs", func(t *testing.T) {
+		assert.ElementsMatch(t, []uint{1, 2}, cache.Keys())
+	})
+
 	t.Run("Clear removes all items", func(t *testing.T) {
 		cache.Clear()
 		items := cache.GetAll()
 		assert.Len(t, items, 0)
+		assert.Empty(t, cache.Keys())
 	})
 }
 
@@ -111,3 +127,927 @@ func TestCacheManagerQuery(t *testing.T) {
 		assert.Equal(t, "John Smith", results[0].Name)
 	})
 }
+
+func TestCacheManagerSetLoader(t *testing.T) {
+	cache := NewCacheManager[models.User](&mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+	}})
+	assert.NoError(t, cache.Refresh())
+
+	cache.SetLoader(&mockUserLoader{users: []models.User{
+		{ID: 2, Name: "Jane", Email: "jane@example.com"},
+		{ID: 3, Name: "John Smith", Email: "john.smith@example.com"},
+	}})
+
+	t.Run("SetLoader keeps cached data until refresh", func(t *testing.T) {
+		user, err := cache.Get(1)
+		assert.NoError(t, err)
+		assert.Equal(t, "John", user.Name)
+	})
+
+	t.Run("Refresh uses the new loader", func(t *testing.T) {
+		assert.NoError(t, cache.Refresh())
+		assert.Len(t, cache.GetAll(), 2)
+
+		_, err := cache.Get(1)
+		assert.Error(t, err)
+		user, err := cache.Get(2)
+		assert.NoError(t, err)
+		assert.Equal(t, "Jane", user.Name)
+	})
+}
+
+func TestCacheManagerExpiryPolicy(t *testing.T) {
+	testUsers := []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+		{ID: 2, Name: "Jane", Email: "jane@example.com"},
+	}
+	nameCondition := StringFieldCondition[models.User]{
+		FieldExtractor: func(u models.User) string { return u.Name },
+		Value:          "J",
+		Operation:      "startsWith",
+	}
+
+	newExpiredCache := func(t *testing.T, getPolicy, listPolicy ExpiryPolicy) *CacheManager[models.User] {
+		cache := NewCacheManager[models.User](&mockUserLoader{users: testUsers}).
+			WithTTL(time.Minute).
+			WithExpiryPolicy(getPolicy, listPolicy)
+		assert.NoError(t, cache.Refresh())
+		cache.lastFetch = time.Now().Add(-time.Hour)
+		return cache
+	}
+
+	getCases := []struct {
+		policy  ExpiryPolicy
+		wantErr string
+	}{
+		{ExpiryError, "cache expired"},
+		{ExpiryServeStale, ""},
+		{ExpiryEmpty, "item with ID 1 not found"},
+	}
+	listCases := []struct {
+		policy  ExpiryPolicy
+		wantNil bool
+		wantLen int
+	}{
+		{ExpiryError, true, 0},
+		{ExpiryServeStale, false, 2},
+		{ExpiryEmpty, false, 0},
+	}
+
+	for _, gc := range getCases {
+		for _, lc := range listCases {
+			t.Run(string(gc.policy)+"/"+string(lc.policy), func(t *testing.T) {
+				cache := newExpiredCache(t, gc.policy, lc.policy)
+
+				user, err := cache.Get(1)
+				if gc.wantErr == "" {
+					assert.NoError(t, err)
+					assert.Equal(t, "John", user.Name)
+				} else {
+					assert.EqualError(t, err, gc.wantErr)
+				}
+
+				for name, items := range map[string]int{
+					"GetAll": len(cache.GetAll()),
+					"Keys":   len(cache.Keys()),
+					"Query":  len(cache.Query(nameCondition)),
+				} {
+					assert.Equal(t, lc.wantLen, items, name)
+				}
+				assert.Equal(t, gc.wantErr == "", cache.Has(1), "Has should agree with Get")
+				assert.Equal(t, lc.wantNil, cache.GetAll() == nil)
+				assert.Equal(t, lc.wantNil, cache.Keys() == nil)
+				assert.Equal(t, lc.wantNil, cache.Query(nameCondition) == nil)
+			})
+		}
+	}
+}
+
+func TestCacheManagerGetUnchecked(t *testing.T) {
+	cache := NewCacheManager[models.User](&mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+	}}).WithTTL(time.Minute)
+	assert.NoError(t, cache.Refresh())
+	assert.False(t, cache.IsStale())
+
+	cache.lastFetch = time.Now().Add(-time.Hour)
+	assert.True(t, cache.IsStale())
+
+	_, err := cache.Get(1)
+	assert.Error(t, err)
+
+	user, ok := cache.GetUnchecked(1)
+	assert.True(t, ok, "GetUnchecked should serve stale data")
+	assert.Equal(t, "John", user.Name)
+
+	_, ok = cache.GetUnchecked(999)
+	assert.False(t, ok)
+}
+
+func newLargeUserCache(tb testing.TB) *CacheManager[models.User] {
+	users := make([]models.User, 1000)
+	for i := range users {
+		users[i] = models.User{ID: uint(i + 1), Name: "User", Email: "user@example.com"}
+	}
+	cache := NewCacheManager[models.User](&mockUserLoader{users: users}).WithTTL(time.Hour)
+	if err := cache.Refresh(); err != nil {
+		tb.Fatal(err)
+	}
+	return cache
+}
+
+func BenchmarkCacheManagerGet(b *testing.B) {
+	cache := newLargeUserCache(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for id := uint(1); id <= 1000; id++ {
+			_, _ = cache.Get(id)
+		}
+	}
+}
+
+func BenchmarkCacheManagerGetUnchecked(b *testing.B) {
+	cache := newLargeUserCache(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for id := uint(1); id <= 1000; id++ {
+			_, _ = cache.GetUnchecked(id)
+		}
+	}
+}
+
+type mockIncrementalUserLoader struct {
+	mockUserLoader
+	changed []models.User
+	column  string
+	since   time.Time
+}
+
+func (m *mockIncrementalUserLoader) LoadSince(column string, since time.Time) ([]models.User, error) {
+	m.column = column
+	m.since = since
+	return m.changed, m.err
+}
+
+func TestCacheManagerRefreshIncremental(t *testing.T) {
+	loader := &mockIncrementalUserLoader{mockUserLoader: mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+		{ID: 2, Name: "Jane", Email: "jane@example.com"},
+	}}}
+	cache := NewCacheManager[models.User](loader)
+
+	t.Run("first refresh loads everything", func(t *testing.T) {
+		assert.NoError(t, cache.RefreshIncremental("updated_at"))
+		assert.Len(t, cache.GetAll(), 2)
+		assert.Empty(t, loader.column, "LoadSince should not be used without a watermark")
+	})
+
+	t.Run("merges changed rows by ID", func(t *testing.T) {
+		watermark := cache.lastFetch
+		loader.changed = []models.User{
+			{ID: 2, Name: "Jane Doe", Email: "jane@example.com"},
+			{ID: 3, Name: "John Smith", Email: "john.smith@example.com"},
+		}
+
+		assert.NoError(t, cache.RefreshIncremental("updated_at"))
+		assert.Equal(t, "updated_at", loader.column)
+		assert.Equal(t, watermark, loader.since)
+		assert.True(t, cache.lastFetch.After(watermark))
+
+		assert.Len(t, cache.GetAll(), 3)
+		user, err := cache.Get(1)
+		assert.NoError(t, err)
+		assert.Equal(t, "John", user.Name, "unchanged rows should be kept")
+		user, err = cache.Get(2)
+		assert.NoError(t, err)
+		assert.Equal(t, "Jane Doe", user.Name, "changed rows should be updated")
+	})
+
+	t.Run("requires an incremental loader", func(t *testing.T) {
+		plain := NewCacheManager[models.User](&mockUserLoader{})
+		assert.Error(t, plain.RefreshIncremental("updated_at"))
+	})
+}
+
+func TestCacheManagerVerify(t *testing.T) {
+	loader := &mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+		{ID: 2, Name: "Jane", Email: "jane@example.com"},
+		{ID: 3, Name: "John Smith", Email: "john.smith@example.com"},
+	}}
+	cache := NewCacheManager[models.User](loader)
+	assert.NoError(t, cache.Refresh())
+
+	t.Run("in sync after refresh", func(t *testing.T) {
+		result, err := cache.Verify(context.Background())
+		assert.NoError(t, err)
+		assert.True(t, result.InSync())
+	})
+
+	t.Run("reports drift without replacing data", func(t *testing.T) {
+		loader.users = []models.User{
+			{ID: 2, Name: "Jane Doe", Email: "jane@example.com"},
+			{ID: 3, Name: "John Smith", Email: "john.smith@example.com"},
+			{ID: 4, Name: "Bob", Email: "bob@example.com"},
+		}
+
+		result, err := cache.Verify(context.Background())
+		assert.NoError(t, err)
+		assert.False(t, result.InSync())
+		assert.Equal(t, []uint{1}, result.OnlyInCache)
+		assert.Equal(t, []uint{4}, result.OnlyInSource)
+		assert.Equal(t, []uint{2}, result.Different)
+
+		user, err := cache.Get(2)
+		assert.NoError(t, err)
+		assert.Equal(t, "Jane", user.Name, "Verify should not modify the cache")
+	})
+
+	t.Run("propagates loader errors", func(t *testing.T) {
+		loader.err = errors.New("connection refused")
+		defer func() { loader.err = nil }()
+
+		_, err := cache.Verify(context.Background())
+		assert.EqualError(t, err, "connection refused")
+	})
+
+	t.Run("honors a cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := cache.Verify(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestCacheManagerIterators(t *testing.T) {
+	cache := NewCacheManager[models.User](&mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+		{ID: 2, Name: "Jane", Email: "jane@example.com"},
+		{ID: 3, Name: "John Smith", Email: "john.smith@example.com"},
+	}})
+	assert.NoError(t, cache.Refresh())
+
+	t.Run("All yields every pair", func(t *testing.T) {
+		seen := make(map[uint]string)
+		for id, user := range cache.All() {
+			assert.Equal(t, id, user.ID)
+			seen[id] = user.Name
+		}
+		assert.Equal(t, map[uint]string{1: "John", 2: "Jane", 3: "John Smith"}, seen)
+	})
+
+	t.Run("All stops early", func(t *testing.T) {
+		count := 0
+		for range cache.All() {
+			count++
+			break
+		}
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("loop body may call the cache", func(t *testing.T) {
+		for range cache.All() {
+			cache.Clear() // would deadlock if the read lock were held
+		}
+		assert.NoError(t, cache.Refresh())
+	})
+
+	t.Run("Matching yields matching items", func(t *testing.T) {
+		condition := StringFieldCondition[models.User]{
+			FieldExtractor: func(u models.User) string { return u.Name },
+			Value:          "John",
+			Operation:      "contains",
+		}
+		var names []string
+		for user := range cache.Matching(condition) {
+			names = append(names, user.Name)
+		}
+		assert.ElementsMatch(t, []string{"John", "John Smith"}, names)
+	})
+
+	t.Run("expired cache yields nothing", func(t *testing.T) {
+		expired := NewCacheManager[models.User](&mockUserLoader{users: []models.User{{ID: 1}}}).WithTTL(time.Minute)
+		assert.NoError(t, expired.Refresh())
+		expired.lastFetch = time.Now().Add(-time.Hour)
+		for range expired.All() {
+			t.Fatal("expired cache should not yield")
+		}
+	})
+}
+
+func TestCacheManagerCompact(t *testing.T) {
+	users := make([]models.User, 1000)
+	for i := range users {
+		users[i] = models.User{ID: uint(i + 1), Name: "User"}
+	}
+	cache := NewCacheManager[models.User](&mockUserLoader{users: users})
+	assert.NoError(t, cache.Refresh())
+
+	for id := uint(11); id <= 1000; id++ {
+		delete(cache.data, id)
+	}
+	before := reflect.ValueOf(cache.data).UnsafePointer()
+
+	cache.Compact()
+
+	assert.NotEqual(t, before, reflect.ValueOf(cache.data).UnsafePointer(), "Compact should allocate a new map")
+	assert.Len(t, cache.GetAll(), 10)
+	user, err := cache.Get(10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(10), user.ID)
+}
+
+func TestCacheManagerMerge(t *testing.T) {
+	newShard := func(users ...models.User) *CacheManager[models.User] {
+		shard := NewCacheManager[models.User](&mockUserLoader{users: users})
+		assert.NoError(t, shard.Refresh())
+		return shard
+	}
+
+	t.Run("keeps existing items on conflict", func(t *testing.T) {
+		a := newShard(models.User{ID: 1, Name: "John"}, models.User{ID: 2, Name: "Jane"})
+		b := newShard(models.User{ID: 2, Name: "Jane Doe"}, models.User{ID: 3, Name: "Bob"})
+
+		a.Merge(b, MergeKeepExisting)
+
+		assert.ElementsMatch(t, []uint{1, 2, 3}, a.Keys())
+		user, err := a.Get(2)
+		assert.NoError(t, err)
+		assert.Equal(t, "Jane", user.Name)
+		assert.Len(t, b.Keys(), 2, "the source cache should be unchanged")
+	})
+
+	t.Run("overwrites items on conflict", func(t *testing.T) {
+		a := newShard(models.User{ID: 1, Name: "John"}, models.User{ID: 2, Name: "Jane"})
+		b := newShard(models.User{ID: 2, Name: "Jane Doe"}, models.User{ID: 3, Name: "Bob"})
+
+		a.Merge(b, MergeOverwrite)
+
+		assert.ElementsMatch(t, []uint{1, 2, 3}, a.Keys())
+		user, err := a.Get(2)
+		assert.NoError(t, err)
+		assert.Equal(t, "Jane Doe", user.Name)
+	})
+
+	t.Run("scatter-gather into an empty cache", func(t *testing.T) {
+		target := NewCacheManager[models.User](&mockUserLoader{})
+		shards := []*CacheManager[models.User]{
+			newShard(models.User{ID: 1}, models.User{ID: 2}),
+			newShard(models.User{ID: 3}),
+			newShard(models.User{ID: 4}, models.User{ID: 5}),
+		}
+		for _, shard := range shards {
+			target.Merge(shard, MergeOverwrite)
+		}
+		assert.ElementsMatch(t, []uint{1, 2, 3, 4, 5}, target.Keys())
+		assert.Equal(t, shards[0].lastFetch, target.lastFetch, "merged data should be as old as the oldest shard")
+	})
+
+	t.Run("concurrent merges in both directions", func(t *testing.T) {
+		a := newShard(models.User{ID: 1})
+		b := newShard(models.User{ID: 2})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(2)
+			go func() { defer wg.Done(); a.Merge(b, MergeKeepExisting) }()
+			go func() { defer wg.Done(); b.Merge(a, MergeKeepExisting) }()
+		}
+		wg.Wait()
+
+		assert.ElementsMatch(t, []uint{1, 2}, a.Keys())
+		assert.ElementsMatch(t, []uint{1, 2}, b.Keys())
+	})
+
+	t.Run("merging into itself is a no-op", func(t *testing.T) {
+		a := newShard(models.User{ID: 1})
+		a.Merge(a, MergeOverwrite)
+		assert.Equal(t, []uint{1}, a.Keys())
+	})
+}
+
+func TestCacheManagerNotInitialized(t *testing.T) {
+	cache := NewCacheManager[models.User](&mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+	}}).WithTTL(time.Minute)
+	condition := StringFieldCondition[models.User]{
+		FieldExtractor: func(u models.User) string { return u.Name },
+		Value:          "John",
+		Operation:      "eq",
+	}
+
+	t.Run("reads before Refresh report not initialized", func(t *testing.T) {
+		_, err := cache.Get(1)
+		assert.ErrorIs(t, err, ErrCacheNotInitialized)
+		assert.False(t, cache.Has(1))
+		assert.Nil(t, cache.GetAll())
+		assert.Nil(t, cache.Keys())
+		assert.Nil(t, cache.Query(condition))
+	})
+
+	t.Run("Refresh initializes the cache", func(t *testing.T) {
+		assert.NoError(t, cache.Refresh())
+		user, err := cache.Get(1)
+		assert.NoError(t, err)
+		assert.Equal(t, "John", user.Name)
+	})
+
+	t.Run("Clear keeps the cache initialized", func(t *testing.T) {
+		cache.Clear()
+		_, err := cache.Get(1)
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrCacheNotInitialized)
+		assert.NotNil(t, cache.GetAll())
+	})
+}
+
+func TestCacheManagerExpiryFunc(t *testing.T) {
+	var versionBumped atomic.Bool
+	cache := NewCacheManager[models.User](&mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+	}}).WithExpiryFunc(versionBumped.Load)
+	assert.NoError(t, cache.Refresh())
+
+	t.Run("fresh while the predicate is false", func(t *testing.T) {
+		assert.False(t, cache.IsStale())
+		_, err := cache.Get(1)
+		assert.NoError(t, err)
+	})
+
+	t.Run("expired once the predicate is true", func(t *testing.T) {
+		versionBumped.Store(true)
+		assert.True(t, cache.IsStale())
+		_, err := cache.Get(1)
+		assert.EqualError(t, err, "cache expired")
+		assert.Nil(t, cache.GetAll())
+	})
+
+	t.Run("stays expired until the next refresh", func(t *testing.T) {
+		versionBumped.Store(false)
+		assert.True(t, cache.IsStale())
+
+		assert.NoError(t, cache.Refresh())
+		assert.False(t, cache.IsStale())
+		_, err := cache.Get(1)
+		assert.NoError(t, err)
+	})
+}
+
+func TestCacheManagerGetWithMeta(t *testing.T) {
+	loader := &mockIncrementalUserLoader{mockUserLoader: mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+		{ID: 2, Name: "Jane", Email: "jane@example.com"},
+	}}}
+	cache := NewCacheManager[models.User](loader)
+
+	_, _, ok := cache.GetWithMeta(1)
+	assert.False(t, ok, "a never-refreshed cache has no entries")
+
+	before := time.Now()
+	assert.NoError(t, cache.Refresh())
+
+	t.Run("reports the refresh time", func(t *testing.T) {
+		user, meta, ok := cache.GetWithMeta(1)
+		assert.True(t, ok)
+		assert.Equal(t, "John", user.Name)
+		assert.False(t, meta.LoadedAt.Before(before))
+		assert.Equal(t, cache.lastFetch, meta.LoadedAt)
+		assert.True(t, meta.LastAccess.IsZero())
+		assert.Zero(t, meta.AccessCount)
+
+		_, _, ok = cache.GetWithMeta(3)
+		assert.False(t, ok)
+	})
+
+	t.Run("updates on incremental upsert", func(t *testing.T) {
+		_, first, _ := cache.GetWithMeta(1)
+		loader.changed = []models.User{{ID: 2, Name: "Jane Doe", Email: "jane@example.com"}}
+		assert.NoError(t, cache.RefreshIncremental("updated_at"))
+
+		_, unchanged, _ := cache.GetWithMeta(1)
+		assert.Equal(t, first.LoadedAt, unchanged.LoadedAt, "untouched entries keep their load time")
+		_, updated, _ := cache.GetWithMeta(2)
+		assert.True(t, updated.LoadedAt.After(first.LoadedAt))
+	})
+
+	t.Run("tracks access when enabled", func(t *testing.T) {
+		cache.WithAccessTracking(true)
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			_, err := cache.Get(1)
+			assert.NoError(t, err)
+		}
+		_, meta, _ := cache.GetWithMeta(1)
+		assert.Equal(t, uint64(3), meta.AccessCount)
+		assert.False(t, meta.LastAccess.Before(start))
+	})
+
+	t.Run("follows the get expiry policy", func(t *testing.T) {
+		cache.lastFetch = time.Now().Add(-time.Hour)
+		cache.WithTTL(time.Minute)
+		_, _, ok := cache.GetWithMeta(1)
+		assert.False(t, ok)
+
+		cache.WithExpiryPolicy(ExpiryServeStale, ExpiryError)
+		_, _, ok = cache.GetWithMeta(1)
+		assert.True(t, ok)
+	})
+
+	t.Run("clear drops metadata", func(t *testing.T) {
+		cache.Clear()
+		assert.Empty(t, cache.meta)
+	})
+}
+
+func TestCacheManagerReset(t *testing.T) {
+	loader := &mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+	}}
+	refreshes := 0
+	cache := NewCacheManager[models.User](loader).
+		WithTTL(time.Minute).
+		OnRefresh(func(added, removed, changed int) { refreshes++ })
+	assert.NoError(t, cache.Refresh())
+	_, _ = cache.Get(1)
+	_, _ = cache.Get(2)
+
+	t.Run("clear keeps fetch time and stats", func(t *testing.T) {
+		cache.Clear()
+		_, err := cache.Get(1)
+		assert.EqualError(t, err, "item with ID 1 not found")
+		assert.NotEqual(t, CacheStats{}, cache.Stats())
+		assert.NoError(t, cache.Refresh())
+	})
+
+	t.Run("reset returns to the constructed state", func(t *testing.T) {
+		cache.Reset()
+		assert.Equal(t, CacheStats{}, cache.Stats())
+		assert.Empty(t, cache.meta)
+		_, err := cache.Get(1)
+		assert.ErrorIs(t, err, ErrCacheNotInitialized)
+	})
+
+	t.Run("configuration is retained", func(t *testing.T) {
+		assert.NoError(t, cache.Refresh())
+		assert.Equal(t, time.Minute, cache.ttl)
+		assert.Equal(t, 3, refreshes, "hooks survive the reset")
+		_, err := cache.Get(1)
+		assert.NoError(t, err)
+	})
+}
+
+func TestCacheManagerPatch(t *testing.T) {
+	cache := NewCacheManager[models.User](&mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+	}})
+	assert.NoError(t, cache.Refresh())
+
+	t.Run("updates a single field", func(t *testing.T) {
+		user, ok := cache.Patch(1, func(u *models.User) { u.Email = "john@example.org" })
+		assert.True(t, ok)
+		assert.Equal(t, "john@example.org", user.Email)
+		assert.Equal(t, "John", user.Name)
+
+		cached, err := cache.Get(1)
+		assert.NoError(t, err)
+		assert.Equal(t, user, cached)
+	})
+
+	t.Run("missing items are not created", func(t *testing.T) {
+		called := false
+		_, ok := cache.Patch(2, func(u *models.User) { called = true })
+		assert.False(t, ok)
+		assert.False(t, called)
+		_, err := cache.Get(2)
+		assert.Error(t, err)
+	})
+
+	t.Run("concurrent patches keep every update", func(t *testing.T) {
+		cache.Patch(1, func(u *models.User) { u.Name = "" })
+
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				cache.Patch(1, func(u *models.User) { u.Name += "x" })
+			}()
+		}
+		wg.Wait()
+
+		user, err := cache.Get(1)
+		assert.NoError(t, err)
+		assert.Len(t, user.Name, 100)
+	})
+}
+
+func TestCacheManagerWithPermanent(t *testing.T) {
+	cache := NewCacheManager[models.User](&mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+	}}).WithTTL(time.Millisecond).WithExpiryFunc(func() bool { return true }).WithPermanent()
+	assert.NoError(t, cache.Refresh())
+
+	cache.lastFetch = time.Now().Add(-24 * 365 * time.Hour)
+	assert.False(t, cache.IsStale())
+	_, err := cache.Get(1)
+	assert.NoError(t, err)
+	assert.Len(t, cache.GetAll(), 1)
+}
+
+func TestCacheManagerSetAndDelete(t *testing.T) {
+	loader := &mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+	}}
+	cache := NewCacheManager[models.User](loader).WithTTL(time.Minute)
+	assert.NoError(t, cache.Refresh())
+
+	cache.Set(models.User{ID: 1, Name: "John Doe"})
+	cache.Set(models.User{ID: 2, Name: "Jane"})
+	user, err := cache.Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "John Doe", user.Name)
+	user, err = cache.Get(2)
+	assert.NoError(t, err)
+	assert.Equal(t, "Jane", user.Name)
+
+	cache.Delete(1)
+	cache.Delete(9)
+	assert.False(t, cache.Has(1))
+	assert.Equal(t, []uint{2}, cache.Keys())
+
+	t.Run("set keeps the TTL running", func(t *testing.T) {
+		cache.lastFetch = time.Now().Add(-2 * time.Minute)
+		cache.Set(models.User{ID: 3, Name: "Bob"})
+		_, err := cache.Get(3)
+		assert.Error(t, err, "the cache is still expired")
+	})
+
+	t.Run("set and touch restarts the TTL", func(t *testing.T) {
+		cache.SetAndTouch(models.User{ID: 3, Name: "Bob"})
+		user, err := cache.Get(3)
+		assert.NoError(t, err)
+		assert.Equal(t, "Bob", user.Name)
+	})
+
+	t.Run("a refresh replaces single writes", func(t *testing.T) {
+		assert.NoError(t, cache.Refresh())
+		assert.Equal(t, []uint{1}, cache.Keys())
+	})
+}
+
+func TestCacheManagerDeleteMany(t *testing.T) {
+	cache := newLargeUserCache(t)
+	assert.Equal(t, 3, cache.DeleteMany([]uint{1, 2, 3, 2, 5000}))
+	assert.Len(t, cache.Keys(), 997)
+	assert.False(t, cache.Has(2))
+	assert.Zero(t, cache.DeleteMany(nil))
+}
+
+func TestCacheManagerWithoutLoader(t *testing.T) {
+	cache := NewCacheManager[models.User](nil).WithTTL(time.Minute)
+	assert.Empty(t, cache.GetAll())
+	_, err := cache.Get(1)
+	assert.False(t, errors.Is(err, ErrCacheNotInitialized), "a push-only cache is always initialized")
+
+	cache.SetAll([]models.User{{ID: 1, Name: "John"}, {ID: 2, Name: "Jane"}})
+	cache.Set(models.User{ID: 3, Name: "Bob"})
+	user, err := cache.Get(2)
+	assert.NoError(t, err)
+	assert.Equal(t, "Jane", user.Name)
+	assert.ElementsMatch(t, []uint{1, 2, 3}, cache.Keys())
+
+	assert.ErrorIs(t, cache.Refresh(), ErrNoLoader)
+	assert.ErrorIs(t, cache.RefreshContext(context.Background()), ErrNoLoader)
+	assert.ErrorIs(t, cache.RefreshIncremental(""), ErrNoLoader)
+	_, err = cache.Verify(context.Background())
+	assert.ErrorIs(t, err, ErrNoLoader)
+	assert.Len(t, cache.GetAll(), 3, "a failed refresh keeps pushed items")
+}
+
+func TestCacheManagerOnExpire(t *testing.T) {
+	var fired atomic.Int32
+	cache := NewCacheManager[models.User](&mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+	}}).WithTTL(time.Minute).OnExpire(func() { fired.Add(1) })
+
+	cache.IsStale()
+	_, _ = cache.Get(1)
+	assert.Zero(t, fired.Load(), "a never-refreshed cache has not expired")
+
+	assert.NoError(t, cache.Refresh())
+	_, _ = cache.Get(1)
+	assert.Zero(t, fired.Load())
+
+	t.Run("fires once per expiry", func(t *testing.T) {
+		cache.lastFetch = time.Now().Add(-time.Hour)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = cache.Get(1)
+				cache.Has(1)
+				cache.GetAll()
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), fired.Load())
+	})
+
+	t.Run("refresh arms the next expiry", func(t *testing.T) {
+		assert.NoError(t, cache.Refresh())
+		_, _ = cache.Get(1)
+		assert.Equal(t, int32(1), fired.Load())
+
+		cache.lastFetch = time.Now().Add(-time.Hour)
+		cache.Keys()
+		cache.Keys()
+		assert.Equal(t, int32(2), fired.Load())
+	})
+
+	t.Run("hooks may call back into the cache", func(t *testing.T) {
+		var refreshed *CacheManager[models.User]
+		refreshed = NewCacheManager[models.User](&mockUserLoader{users: []models.User{{ID: 1}}}).
+			WithTTL(time.Minute).
+			OnExpire(func() { assert.NoError(t, refreshed.Refresh()) })
+		assert.NoError(t, refreshed.Refresh())
+		refreshed.lastFetch = time.Now().Add(-time.Hour)
+
+		_, err := refreshed.Get(1)
+		assert.Error(t, err, "the read that observes expiry still sees it")
+		_, err = refreshed.Get(1)
+		assert.NoError(t, err)
+	})
+}
+
+// slowUserLoader blocks each Load for delay
+type slowUserLoader struct {
+	mockUserLoader
+	delay time.Duration
+}
+
+func (m *slowUserLoader) Load() ([]models.User, error) {
+	time.Sleep(m.delay)
+	return m.mockUserLoader.Load()
+}
+
+func TestCacheManagerRefreshDeduplicatesConcurrentCalls(t *testing.T) {
+	refreshAll := func(cache *CacheManager[models.User], loader *blockingUserLoader) []error {
+		errs := make([]error, 20)
+		var wg sync.WaitGroup
+		for i := range errs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = cache.Refresh()
+			}()
+		}
+		time.Sleep(20 * time.Millisecond) // let every call join the flight
+		close(loader.release)
+		wg.Wait()
+		return errs
+	}
+
+	t.Run("callers share one load", func(t *testing.T) {
+		loader := &blockingUserLoader{release: make(chan struct{})}
+		var refreshes atomic.Int32
+		cache := NewCacheManager[models.User](loader).OnRefresh(func(added, removed, changed int) {
+			refreshes.Add(1)
+		})
+
+		for _, err := range refreshAll(cache, loader) {
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, int32(1), loader.loads.Load())
+		assert.Equal(t, int32(1), refreshes.Load(), "hooks fire once per load")
+		_, err := cache.Get(1)
+		assert.NoError(t, err)
+	})
+
+	t.Run("callers share the error", func(t *testing.T) {
+		loader := &blockingUserLoader{release: make(chan struct{})}
+		loader.fail(errors.New("database down"))
+		cache := NewCacheManager[models.User](loader)
+
+		for _, err := range refreshAll(cache, loader) {
+			assert.EqualError(t, err, "database down")
+		}
+		assert.Equal(t, int32(1), loader.loads.Load())
+		assert.Len(t, cache.RecentErrors(), 1)
+	})
+
+	t.Run("sequential calls each load", func(t *testing.T) {
+		loader := &countingUserLoader{}
+		cache := NewCacheManager[models.User](loader)
+		assert.NoError(t, cache.Refresh())
+		assert.NoError(t, cache.Refresh())
+		assert.Equal(t, int32(2), loader.loads.Load())
+	})
+}
+
+func TestCacheManagerRefreshContext(t *testing.T) {
+	users := []models.User{{ID: 1, Name: "John", Email: "john@example.com"}}
+
+	t.Run("loads within the deadline", func(t *testing.T) {
+		cache := NewCacheManager[models.User](&mockUserLoader{users: users})
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		assert.NoError(t, cache.RefreshContext(ctx))
+		_, err := cache.Get(1)
+		assert.NoError(t, err)
+	})
+
+	t.Run("timeout reports the elapsed time", func(t *testing.T) {
+		cache := NewCacheManager[models.User](&slowUserLoader{
+			mockUserLoader: mockUserLoader{users: users},
+			delay:          200 * time.Millisecond,
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		err := cache.RefreshContext(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Regexp(t, `^refresh timed out after \d+ms: context deadline exceeded$`, err.Error())
+
+		elapsed, parseErr := time.ParseDuration(regexp.MustCompile(`\d+ms`).FindString(err.Error()))
+		assert.NoError(t, parseErr)
+		assert.GreaterOrEqual(t, elapsed, 20*time.Millisecond)
+		assert.Less(t, elapsed, 200*time.Millisecond, "RefreshContext must not wait for the slow load")
+
+		_, err = cache.Get(1)
+		assert.ErrorIs(t, err, ErrCacheNotInitialized, "a timed out load is discarded")
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		cache := NewCacheManager[models.User](&mockUserLoader{users: users})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.ErrorIs(t, cache.RefreshContext(ctx), context.Canceled)
+	})
+
+	t.Run("context loaders get the context", func(t *testing.T) {
+		loader := &ctxUserLoader{mockUserLoader: mockUserLoader{users: users}, stopped: make(chan error, 1)}
+		cache := NewCacheManager[models.User](loader)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		assert.ErrorIs(t, cache.RefreshContext(ctx), context.DeadlineExceeded)
+		select {
+		case err := <-loader.stopped:
+			assert.ErrorIs(t, err, context.DeadlineExceeded, "the load observed the deadline")
+		case <-time.After(time.Second):
+			t.Fatal("the load was not cancelled")
+		}
+	})
+}
+
+// ctxUserLoader blocks in LoadCtx until ctx is done and reports why
+type ctxUserLoader struct {
+	mockUserLoader
+	stopped chan error
+}
+
+func (l *ctxUserLoader) LoadCtx(ctx context.Context) ([]models.User, error) {
+	<-ctx.Done()
+	l.stopped <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+// countingCondition matches every item and counts how often it was asked
+type countingCondition struct {
+	calls int
+}
+
+func (c *countingCondition) Match(models.User) bool {
+	c.calls++
+	return true
+}
+
+func TestCacheManagerQueryLimit(t *testing.T) {
+	cache := newLargeUserCache(t)
+
+	t.Run("stops scanning after k matches", func(t *testing.T) {
+		condition := &countingCondition{}
+		results := cache.QueryLimit(condition, 5)
+		assert.Len(t, results, 5)
+		assert.Equal(t, 5, condition.calls)
+	})
+
+	t.Run("returns fewer when fewer match", func(t *testing.T) {
+		condition := NumberFieldCondition[models.User, uint]{
+			FieldExtractor: func(u models.User) uint { return u.ID },
+			Value:          998,
+			Operation:      "gt",
+		}
+		assert.Len(t, cache.QueryLimit(condition, 5), 2)
+	})
+
+	t.Run("non-positive k", func(t *testing.T) {
+		condition := &countingCondition{}
+		assert.Empty(t, cache.QueryLimit(condition, 0))
+		assert.Zero(t, condition.calls)
+	})
+}
@@ -1,18 +1,104 @@
 package cache
 
 import (
+	"container/list"
+	"fmt"
+	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // StringFieldCondition represents a condition for string field comparison
 type StringFieldCondition[T any] struct {
 	FieldExtractor func(T) string
 	Value          string
-	Operation      string // "eq", "contains", "startsWith", "endsWith"
+	Operation      string // "eq", "contains", "startsWith", "endsWith", "regex"
+
+	// FieldName optionally names the extracted field. If an index declared
+	// with WithIndexes has the same name and FieldExtractor is the func it
+	// was declared with, Query looks "eq" and "startsWith" conditions up in
+	// it instead of scanning every item.
+	FieldName string
+
+	// CaseInsensitive compares "eq", "contains", "startsWith" and "endsWith"
+	// ignoring case, with Unicode case folding for "eq"
+	CaseInsensitive bool
+
+	re *regexp.Regexp // compiled Value of a "regex" condition, see NewRegexCondition
+}
+
+// NewRegexCondition creates a "regex" StringFieldCondition with the pattern
+// compiled once, returning an error for an invalid pattern
+func NewRegexCondition[T any](fieldExtractor func(T) string, pattern string) (StringFieldCondition[T], error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return StringFieldCondition[T]{}, fmt.Errorf("failed to compile pattern %q: %w", pattern, err)
+	}
+	return StringFieldCondition[T]{FieldExtractor: fieldExtractor, Value: pattern, Operation: "regex", re: re}, nil
+}
+
+// regexCacheSize bounds how many patterns regexCache keeps
+const regexCacheSize = 256
+
+// regexCache holds the recently used patterns of "regex" conditions built
+// without NewRegexCondition, so a Query compiles each pattern once. It is
+// bounded since patterns often come from request parameters. Invalid
+// patterns are stored as nil.
+var regexCache = &regexLRU{order: list.New(), entries: make(map[string]*list.Element)}
+
+type regexLRU struct {
+	mu      sync.Mutex
+	order   *list.List // most recently used first
+	entries map[string]*list.Element
+}
+
+type regexEntry struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func compiledRegex(pattern string) *regexp.Regexp {
+	c := regexCache
+	c.mu.Lock()
+	if elem, ok := c.entries[pattern]; ok {
+		c.order.MoveToFront(elem)
+		c.mu.Unlock()
+		return elem.Value.(*regexEntry).re
+	}
+	c.mu.Unlock()
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		re = nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[pattern]; !ok {
+		c.entries[pattern] = c.order.PushFront(&regexEntry{pattern: pattern, re: re})
+		if c.order.Len() > regexCacheSize {
+			oldest := c.order.Back()
+			c.order.Remove(oldest)
+			delete(c.entries, oldest.Value.(*regexEntry).pattern)
+		}
+	}
+	return re
 }
 
 func (c StringFieldCondition[T]) Match(item T) bool {
 	fieldValue := c.FieldExtractor(item)
+	if c.CaseInsensitive {
+		if c.Operation == "eq" {
+			return strings.EqualFold(fieldValue, c.Value)
+		}
+		switch c.Operation {
+		case "contains", "startsWith", "endsWith":
+			fieldValue, c.Value = strings.ToLower(fieldValue), strings.ToLower(c.Value)
+		}
+	}
 	switch c.Operation {
 	case "eq":
 		return fieldValue == c.Value
@@ -26,6 +112,14 @@ func (c StringFieldCondition[T]) Match(item T) bool {
 		return fieldValue >= c.Value
 	case "lte":
 		return fieldValue <= c.Value
+	case "regex":
+		re := c.re
+		if re == nil {
+			if re = compiledRegex(c.Value); re == nil {
+				return false
+			}
+		}
+		return re.MatchString(fieldValue)
 	default:
 		return false
 	}
@@ -62,26 +156,61 @@ func (c NumberFieldCondition[T, N]) Match(item T) bool {
 	}
 }
 
-// CompositeCondition combines multiple conditions with AND/OR logic
+// CompositeCondition combines multiple conditions with AND/OR logic. "not"
+// negates its children: with several of them it negates their AND, so it
+// matches the items failing at least one. Without children "and" and "or"
+// match every item while "not" matches nothing, and an unknown Operation
+// never matches.
 type CompositeCondition[T any] struct {
 	Conditions []QueryCondition[T]
-	Operation  string // "and", "or"
+	Operation  string // "and", "or", "not"
+	failures   []atomic.Uint64
 }
 
-func (c CompositeCondition[T]) Match(item T) bool {
-	if len(c.Conditions) == 0 {
-		return true
+// WithProfiling returns a copy of the condition that counts, for "and",
+// how often each child was the one that failed the match. Profiling is off
+// by default to keep the atomic increments off the query path.
+func (c CompositeCondition[T]) WithProfiling() CompositeCondition[T] {
+	c.failures = make([]atomic.Uint64, len(c.Conditions))
+	return c
+}
+
+// FailureCounts returns how often each condition short-circuited an "and"
+// match, in the order of Conditions. It returns nil when profiling is off.
+func (c CompositeCondition[T]) FailureCounts() []uint64 {
+	if c.failures == nil {
+		return nil
+	}
+	counts := make([]uint64, len(c.failures))
+	for i := range c.failures {
+		counts[i] = c.failures[i].Load()
 	}
+	return counts
+}
 
+func (c CompositeCondition[T]) Match(item T) bool {
 	switch c.Operation {
-	case "and":
+	case "not":
 		for _, cond := range c.Conditions {
 			if !cond.Match(item) {
+				return true
+			}
+		}
+		return false
+	case "and":
+		for i, cond := range c.Conditions {
+			if !cond.Match(item) {
+				if i < len(c.failures) {
+					c.failures[i].Add(1)
+				}
 				return false
 			}
 		}
 		return true
 	case "or":
+		if len(c.Conditions) == 0 {
+			return true
+		}
 		for _, cond := range c.Conditions {
 			if cond.Match(item) {
 				return true
@@ -92,3 +221,129 @@ func (c CompositeCondition[T]) Match(item T) bool {
 		return false
 	}
 }
+
+// RangeCondition matches the items whose field lies between Min and Max.
+// Both bounds are inclusive unless flagged exclusive.
+type RangeCondition[T any, N Number] struct {
+	FieldExtractor func(T) N
+	Min, Max       N
+	MinExclusive   bool
+	MaxExclusive   bool
+}
+
+func (c RangeCondition[T, N]) Match(item T) bool {
+	v := c.FieldExtractor(item)
+	if v < c.Min || (c.MinExclusive && v == c.Min) {
+		return false
+	}
+	return v < c.Max || (!c.MaxExclusive && v == c.Max)
+}
+
+// TimeRangeCondition matches the items whose time lies between From and To,
+// e.g. orders created in a reporting window. Both bounds are inclusive
+// unless flagged exclusive; a zero bound leaves that side open.
+type TimeRangeCondition[T any] struct {
+	FieldExtractor func(T) time.Time
+	From, To       time.Time
+	FromExclusive  bool
+	ToExclusive    bool
+}
+
+func (c TimeRangeCondition[T]) Match(item T) bool {
+	v := c.FieldExtractor(item)
+	if !c.From.IsZero() && (v.Before(c.From) || (c.FromExclusive && v.Equal(c.From))) {
+		return false
+	}
+	return c.To.IsZero() || v.Before(c.To) || (!c.ToExclusive && v.Equal(c.To))
+}
+
+// InCondition matches the items whose field is one of Values, e.g. orders
+// whose status is pending or shipped
+type InCondition[T any, V comparable] struct {
+	FieldExtractor func(T) V
+	Values         []V
+
+	set map[V]struct{} // Values as a set, see NewInCondition
+}
+
+// NewInCondition creates an InCondition that looks values up in a set built
+// once, instead of scanning Values for every item
+func NewInCondition[T any, V comparable](fieldExtractor func(T) V, values ...V) InCondition[T, V] {
+	set := make(map[V]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return InCondition[T, V]{FieldExtractor: fieldExtractor, Values: values, set: set}
+}
+
+func (c InCondition[T, V]) Match(item T) bool {
+	fieldValue := c.FieldExtractor(item)
+	if c.set != nil {
+		_, ok := c.set[fieldValue]
+		return ok
+	}
+	return slices.Contains(c.Values, fieldValue)
+}
+
+// NotCondition matches the items its inner condition does not match
+type NotCondition[T any] struct {
+	Inner QueryCondition[T]
+}
+
+func (c NotCondition[T]) Match(item T) bool {
+	return !c.Inner.Match(item)
+}
+
+// Not negates a condition as a whole. Wrapping a CompositeCondition negates
+// the group's result, not each child: Not(And(a, b)) matches the items that
+// fail a or b, which by De Morgan equals Or(Not(a), Not(b)), and
+// Not(Or(a, b)) matches the items that fail both.
+func Not[T any](condition QueryCondition[T]) NotCondition[T] {
+	return NotCondition[T]{Inner: condition}
+}
+
+// And combines conditions so that all of them must match. With no
+// conditions it matches every item.
+func And[T any](conditions ...QueryCondition[T]) CompositeCondition[T] {
+	return CompositeCondition[T]{Conditions: conditions, Operation: "and"}
+}
+
+// Or combines conditions so that at least one of them must match. With no
+// conditions it matches every item, like And and unlike a composite "not".
+func Or[T any](conditions ...QueryCondition[T]) CompositeCondition[T] {
+	return CompositeCondition[T]{Conditions: conditions, Operation: "or"}
+}
+
+// AnyChildCondition matches a parent if any of its children, e.g. the
+// preloaded orders of a user, matches the inner condition. A parent without
+// children never matches.
+type AnyChildCondition[T any, C any] struct {
+	ChildExtractor func(T) []C
+	Inner          QueryCondition[C]
+}
+
+func (c AnyChildCondition[T, C]) Match(item T) bool {
+	for _, child := range c.ChildExtractor(item) {
+		if c.Inner.Match(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllChildCondition matches a parent if all of its children match the inner
+// condition. A parent without children always matches; combine it with a
+// condition on the child count to exclude those.
+type AllChildCondition[T any, C any] struct {
+	ChildExtractor func(T) []C
+	Inner          QueryCondition[C]
+}
+
+func (c AllChildCondition[T, C]) Match(item T) bool {
+	for _, child := range c.ChildExtractor(item) {
+		if !c.Inner.Match(child) {
+			return false
+		}
+	}
+	return true
+}
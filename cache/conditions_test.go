@@ -0,0 +1,375 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompositeConditionProfiling(t *testing.T) {
+	users := []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+		{ID: 2, Name: "Jane", Email: "jane@example.com"},
+		{ID: 3, Name: "John Smith", Email: "john.smith@example.com"},
+	}
+
+	nameCondition := StringFieldCondition[models.User]{
+		FieldExtractor: func(u models.User) string { return u.Name },
+		Value:          "John",
+		Operation:      "startsWith",
+	}
+	emailCondition := StringFieldCondition[models.User]{
+		FieldExtractor: func(u models.User) string { return u.Email },
+		Value:          "smith",
+		Operation:      "contains",
+	}
+
+	t.Run("profiling is off by default", func(t *testing.T) {
+		composite := CompositeCondition[models.User]{
+			Conditions: []QueryCondition[models.User]{nameCondition, emailCondition},
+			Operation:  "and",
+		}
+		for _, u := range users {
+			composite.Match(u)
+		}
+		assert.Nil(t, composite.FailureCounts())
+	})
+
+	t.Run("counts the condition that short-circuited", func(t *testing.T) {
+		composite := CompositeCondition[models.User]{
+			Conditions: []QueryCondition[models.User]{nameCondition, emailCondition},
+			Operation:  "and",
+		}.WithProfiling()
+
+		matched := 0
+		for _, u := range users {
+			if composite.Match(u) {
+				matched++
+			}
+		}
+
+		assert.Equal(t, 1, matched)
+		// Jane fails on the name, John fails on the email
+		assert.Equal(t, []uint64{1, 1}, composite.FailureCounts())
+	})
+
+	t.Run("copies share counters", func(t *testing.T) {
+		composite := CompositeCondition[models.User]{
+			Conditions: []QueryCondition[models.User]{nameCondition},
+			Operation:  "and",
+		}.WithProfiling()
+
+		cache := NewCacheManager[models.User](&mockUserLoader{users: users})
+		assert.NoError(t, cache.Refresh())
+		cache.Query(composite)
+
+		assert.Equal(t, []uint64{1}, composite.FailureCounts())
+	})
+}
+
+type cents int64
+
+type job struct {
+	ID       uint
+	Duration time.Duration
+	Price    cents
+}
+
+func (j job) GetID() uint {
+	return j.ID
+}
+
+type mockJobLoader struct {
+	jobs []job
+}
+
+func (m *mockJobLoader) Load() ([]job, error) {
+	return m.jobs, nil
+}
+
+func TestNumberFieldConditionDefinedTypes(t *testing.T) {
+	jobs := []job{
+		{ID: 1, Duration: 30 * time.Second, Price: 150},
+		{ID: 2, Duration: 2 * time.Minute, Price: 999},
+		{ID: 3, Duration: 5 * time.Minute, Price: 2500},
+	}
+	cache := NewCacheManager[job](&mockJobLoader{jobs: jobs})
+	assert.NoError(t, cache.Refresh())
+
+	t.Run("time.Duration", func(t *testing.T) {
+		condition := NumberFieldCondition[job, time.Duration]{
+			FieldExtractor: func(j job) time.Duration { return j.Duration },
+			Value:          time.Minute,
+			Operation:      "gt",
+		}
+		assert.False(t, condition.Match(jobs[0]))
+		assert.True(t, condition.Match(jobs[1]))
+		assert.Len(t, cache.Query(condition), 2)
+	})
+
+	t.Run("custom integer type", func(t *testing.T) {
+		condition := NumberFieldCondition[job, cents]{
+			FieldExtractor: func(j job) cents { return j.Price },
+			Value:          999,
+			Operation:      "lte",
+		}
+		assert.True(t, condition.Match(jobs[1]))
+		assert.False(t, condition.Match(jobs[2]))
+		assert.Len(t, cache.Query(condition), 2)
+	})
+
+	t.Run("defined types in a composite", func(t *testing.T) {
+		composite := CompositeCondition[job]{
+			Conditions: []QueryCondition[job]{
+				NumberFieldCondition[job, time.Duration]{
+					FieldExtractor: func(j job) time.Duration { return j.Duration },
+					Value:          time.Minute,
+					Operation:      "gte",
+				},
+				NumberFieldCondition[job, cents]{
+					FieldExtractor: func(j job) cents { return j.Price },
+					Value:          1000,
+					Operation:      "lt",
+				},
+			},
+			Operation: "and",
+		}
+		results := cache.Query(composite)
+		assert.Len(t, results, 1)
+		assert.Equal(t, uint(2), results[0].ID)
+	})
+}
+
+func TestNotCondition(t *testing.T) {
+	users := []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+		{ID: 2, Name: "John Smith", Email: "john.smith@example.org"},
+		{ID: 3, Name: "Jane", Email: "jane@example.org"},
+		{ID: 4, Name: "Bob", Email: "bob@example.com"},
+	}
+	john := StringFieldCondition[models.User]{
+		FieldExtractor: func(u models.User) string { return u.Name },
+		Value:          "John",
+		Operation:      "startsWith",
+	}
+	dotCom := StringFieldCondition[models.User]{
+		FieldExtractor: func(u models.User) string { return u.Email },
+		Value:          ".com",
+		Operation:      "endsWith",
+	}
+
+	matching := func(condition QueryCondition[models.User]) []uint {
+		var ids []uint
+		for _, u := range users {
+			if condition.Match(u) {
+				ids = append(ids, u.ID)
+			}
+		}
+		return ids
+	}
+
+	t.Run("negates a single condition", func(t *testing.T) {
+		assert.Equal(t, []uint{3, 4}, matching(Not[models.User](john)))
+	})
+
+	t.Run("negates an and group as a whole", func(t *testing.T) {
+		notBoth := Not[models.User](And[models.User](john, dotCom))
+		assert.Equal(t, []uint{2, 3, 4}, matching(notBoth), "only items matching both are excluded")
+		assert.Equal(t, matching(Or(Not[models.User](john), Not[models.User](dotCom))), matching(notBoth))
+	})
+
+	t.Run("negates an or group as a whole", func(t *testing.T) {
+		neither := Not[models.User](Or[models.User](john, dotCom))
+		assert.Equal(t, []uint{3}, matching(neither))
+		assert.Equal(t, matching(And(Not[models.User](john), Not[models.User](dotCom))), matching(neither))
+	})
+
+	t.Run("composes inside another composite", func(t *testing.T) {
+		johnsNotOnDotCom := And[models.User](john, Not[models.User](dotCom))
+		assert.Equal(t, []uint{2}, matching(johnsNotOnDotCom))
+	})
+
+	t.Run("composite not operation", func(t *testing.T) {
+		notJohn := CompositeCondition[models.User]{Conditions: []QueryCondition[models.User]{john}, Operation: "not"}
+		assert.Equal(t, matching(Not[models.User](john)), matching(notJohn))
+
+		notBoth := CompositeCondition[models.User]{Conditions: []QueryCondition[models.User]{john, dotCom}, Operation: "not"}
+		assert.Equal(t, matching(Not[models.User](And[models.User](john, dotCom))), matching(notBoth), "several children negate their and")
+
+		assert.Empty(t, matching(CompositeCondition[models.User]{Operation: "not"}))
+	})
+
+	t.Run("empty composites", func(t *testing.T) {
+		all := []uint{1, 2, 3, 4}
+		assert.Equal(t, all, matching(And[models.User]()))
+		assert.Equal(t, all, matching(Or[models.User]()))
+		assert.Empty(t, matching(CompositeCondition[models.User]{Operation: "not"}))
+		assert.Empty(t, matching(CompositeCondition[models.User]{Operation: "xor"}))
+		assert.Equal(t, []uint{1, 2}, matching(And[models.User](Or[models.User](), john)))
+	})
+}
+
+func TestChildConditions(t *testing.T) {
+	users := []models.UserV2{
+		{ID: 1, Name: "John", Orders: []models.Order{{ID: 1, Amount: 500}, {ID: 2, Amount: 1500}}},
+		{ID: 2, Name: "Jane", Orders: []models.Order{{ID: 3, Amount: 1200}, {ID: 4, Amount: 2000}}},
+		{ID: 3, Name: "Bob", Orders: []models.Order{{ID: 5, Amount: 100}}},
+		{ID: 4, Name: "Alice"},
+	}
+	cache := NewCacheManager[models.UserV2](&sliceLoader[models.UserV2]{items: users})
+	assert.NoError(t, cache.Refresh())
+
+	orders := func(u models.UserV2) []models.Order { return u.Orders }
+	over1000 := NumberFieldCondition[models.Order, float64]{
+		FieldExtractor: func(o models.Order) float64 { return o.Amount },
+		Value:          1000,
+		Operation:      "gt",
+	}
+	ids := func(users []models.UserV2) []uint {
+		var result []uint
+		for _, u := range users {
+			result = append(result, u.ID)
+		}
+		return result
+	}
+
+	t.Run("any child", func(t *testing.T) {
+		result := cache.Query(AnyChildCondition[models.UserV2, models.Order]{ChildExtractor: orders, Inner: over1000})
+		assert.ElementsMatch(t, []uint{1, 2}, ids(result))
+	})
+
+	t.Run("all children", func(t *testing.T) {
+		result := cache.Query(AllChildCondition[models.UserV2, models.Order]{ChildExtractor: orders, Inner: over1000})
+		assert.ElementsMatch(t, []uint{2, 4}, ids(result), "users without orders match vacuously")
+	})
+}
+
+func TestStringFieldConditionRegex(t *testing.T) {
+	sku := func(u models.User) string { return u.Name }
+	skus := []models.User{{ID: 1, Name: "AB-1234"}, {ID: 2, Name: "AB-12"}, {ID: 3, Name: "XY-9876"}}
+	matching := func(c QueryCondition[models.User]) []uint {
+		var ids []uint
+		for _, u := range skus {
+			if c.Match(u) {
+				ids = append(ids, u.ID)
+			}
+		}
+		return ids
+	}
+
+	compiled, err := NewRegexCondition(sku, `^[A-Z]{2}-\d{4}$`)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{1, 3}, matching(compiled))
+
+	literal := StringFieldCondition[models.User]{FieldExtractor: sku, Value: `^AB-`, Operation: "regex"}
+	assert.Equal(t, []uint{1, 2}, matching(literal))
+
+	t.Run("invalid patterns", func(t *testing.T) {
+		_, err := NewRegexCondition(sku, `AB-(`)
+		assert.Error(t, err)
+
+		invalid := StringFieldCondition[models.User]{FieldExtractor: sku, Value: `AB-(`, Operation: "regex"}
+		assert.Empty(t, matching(invalid))
+	})
+	t.Run("literal patterns are cached with a bound", func(t *testing.T) {
+		for i := 0; i < 2*regexCacheSize; i++ {
+			cond := StringFieldCondition[models.User]{FieldExtractor: sku, Value: fmt.Sprintf("^CD-%d", i), Operation: "regex"}
+			matching(cond)
+		}
+		regexCache.mu.Lock()
+		defer regexCache.mu.Unlock()
+		assert.Len(t, regexCache.entries, regexCacheSize)
+		assert.Equal(t, regexCacheSize, regexCache.order.Len())
+	})
+}
+
+func TestStringFieldConditionCaseInsensitive(t *testing.T) {
+	name := func(u models.User) string { return u.Name }
+	john := models.User{ID: 1, Name: "John Smith"}
+
+	for _, tc := range []struct {
+		operation, value string
+	}{
+		{"eq", "JOHN SMITH"},
+		{"contains", "smi"},
+		{"startsWith", "john"},
+		{"endsWith", "SMITH"},
+	} {
+		t.Run(tc.operation, func(t *testing.T) {
+			condition := StringFieldCondition[models.User]{FieldExtractor: name, Value: tc.value, Operation: tc.operation}
+			assert.False(t, condition.Match(john), "comparisons are case-sensitive by default")
+			condition.CaseInsensitive = true
+			assert.True(t, condition.Match(john))
+		})
+	}
+
+	t.Run("eq folds Unicode case", func(t *testing.T) {
+		condition := StringFieldCondition[models.User]{FieldExtractor: name, Value: "straße", Operation: "eq", CaseInsensitive: true}
+		assert.True(t, condition.Match(models.User{Name: "STRAßE"}))
+		assert.True(t, StringFieldCondition[models.User]{FieldExtractor: name, Value: "ǅ", Operation: "eq", CaseInsensitive: true}.Match(models.User{Name: "ǆ"}))
+	})
+}
+
+func TestInCondition(t *testing.T) {
+	orders := []models.Order{{ID: 1, UserID: 1}, {ID: 2, UserID: 2}, {ID: 3, UserID: 3}, {ID: 4, UserID: 2}}
+	matching := func(c QueryCondition[models.Order]) []uint {
+		var ids []uint
+		for _, o := range orders {
+			if c.Match(o) {
+				ids = append(ids, o.ID)
+			}
+		}
+		return ids
+	}
+	userID := func(o models.Order) uint { return o.UserID }
+
+	assert.Equal(t, []uint{1, 2, 4}, matching(NewInCondition(userID, 1, 2)))
+	assert.Equal(t, []uint{1, 2, 4}, matching(InCondition[models.Order, uint]{FieldExtractor: userID, Values: []uint{2, 1}}))
+	assert.Empty(t, matching(NewInCondition(userID)))
+	assert.Empty(t, matching(NewInCondition(userID, 9)))
+}
+
+func TestRangeConditions(t *testing.T) {
+	amount := func(o models.Order) float64 { return o.Amount }
+	createdAt := func(o models.Order) time.Time { return o.CreatedAt }
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	orders := []models.Order{
+		{ID: 1, Amount: 100, CreatedAt: base},
+		{ID: 2, Amount: 150, CreatedAt: base.Add(24 * time.Hour)},
+		{ID: 3, Amount: 200, CreatedAt: base.Add(48 * time.Hour)},
+	}
+	matching := func(c QueryCondition[models.Order]) []uint {
+		var ids []uint
+		for _, o := range orders {
+			if c.Match(o) {
+				ids = append(ids, o.ID)
+			}
+		}
+		return ids
+	}
+
+	t.Run("numbers", func(t *testing.T) {
+		inclusive := RangeCondition[models.Order, float64]{FieldExtractor: amount, Min: 100, Max: 200}
+		assert.Equal(t, []uint{1, 2, 3}, matching(inclusive))
+		inclusive.MinExclusive = true
+		assert.Equal(t, []uint{2, 3}, matching(inclusive))
+		inclusive.MaxExclusive = true
+		assert.Equal(t, []uint{2}, matching(inclusive))
+		assert.Empty(t, matching(RangeCondition[models.Order, float64]{FieldExtractor: amount, Min: 300, Max: 100}))
+	})
+
+	t.Run("times", func(t *testing.T) {
+		window := TimeRangeCondition[models.Order]{FieldExtractor: createdAt, From: base, To: base.Add(24 * time.Hour)}
+		assert.Equal(t, []uint{1, 2}, matching(window))
+		window.ToExclusive = true
+		assert.Equal(t, []uint{1}, matching(window))
+		window.FromExclusive = true
+		assert.Empty(t, matching(window))
+
+		assert.Equal(t, []uint{2, 3}, matching(TimeRangeCondition[models.Order]{FieldExtractor: createdAt, From: base.Add(time.Hour)}))
+		assert.Equal(t, []uint{1, 2, 3}, matching(TimeRangeCondition[models.Order]{FieldExtractor: createdAt}))
+	})
+}
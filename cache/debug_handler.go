@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"slices"
+)
+
+// debugStats is the JSON served at /stats by DebugHandler
+type debugStats struct {
+	expvarStats
+	Evictions uint64 `json:"evictions"`
+}
+
+// DebugHandler returns an http.Handler exposing the cache's internals as
+// JSON: its name, size, age, access counters and last load error at
+// /stats, the sorted IDs at /keys and, if allowDump is set, all items at
+// /dump. The dump can be large and exposes the cached data, so it answers
+// 403 Forbidden unless enabled. Mount the handler below a prefix with
+// http.StripPrefix, e.g. at /debug/cache/users/.
+//
+// Keys and dump show the data as held, even when the cache has expired.
+func DebugHandler[T Identifiable](cm *CacheManager[T], allowDump bool) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		writeDebugJSON(w, debugStats{expvarStats: cm.expvarStats(), Evictions: cm.Stats().Evictions})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		cm.mu.RLock()
+		keys := make([]uint, 0, len(cm.data))
+		for id := range cm.data {
+			keys = append(keys, id)
+		}
+		cm.mu.RUnlock()
+		slices.Sort(keys)
+		writeDebugJSON(w, keys)
+	})
+	mux.HandleFunc("/dump", func(w http.ResponseWriter, r *http.Request) {
+		if !allowDump {
+			http.Error(w, "cache dump is disabled", http.StatusForbidden)
+			return
+		}
+		cm.mu.RLock()
+		items := make([]T, 0, len(cm.data))
+		for _, item := range cm.data {
+			items = append(items, item)
+		}
+		cm.mu.RUnlock()
+		slices.SortFunc(items, func(a, b T) int { return cmp.Compare(a.GetID(), b.GetID()) })
+		writeDebugJSON(w, items)
+	})
+	return mux
+}
+
+func writeDebugJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
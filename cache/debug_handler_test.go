@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugHandler(t *testing.T) {
+	cache := NewSyntheticCacheManager(3, syntheticUser).WithName("users")
+	_, _ = cache.Get(1)
+	_, _ = cache.Get(9)
+
+	get := func(h http.Handler, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	handler := DebugHandler(cache, false)
+
+	t.Run("stats", func(t *testing.T) {
+		rec := get(handler, "/stats")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		var stats map[string]any
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+		assert.Equal(t, "users", stats["name"])
+		assert.Equal(t, float64(3), stats["size"])
+		assert.Equal(t, float64(1), stats["hits"])
+		assert.Equal(t, float64(1), stats["misses"])
+		assert.Contains(t, stats, "age_seconds")
+		assert.Contains(t, stats, "evictions")
+	})
+
+	t.Run("keys", func(t *testing.T) {
+		rec := get(handler, "/keys")
+		assert.JSONEq(t, `[1, 2, 3]`, rec.Body.String())
+	})
+
+	t.Run("dump is disabled by default", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, get(handler, "/dump").Code)
+	})
+
+	t.Run("dump", func(t *testing.T) {
+		rec := get(DebugHandler(cache, true), "/dump")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var users []models.User
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &users))
+		assert.Equal(t, []models.User{syntheticUser(1), syntheticUser(2), syntheticUser(3)}, users)
+	})
+
+	t.Run("mounted below a prefix", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.Handle("/debug/cache/users/", http.StripPrefix("/debug/cache/users", handler))
+		assert.JSONEq(t, `[1, 2, 3]`, get(mux, "/debug/cache/users/keys").Body.String())
+		assert.Equal(t, http.StatusNotFound, get(mux, "/debug/cache/users/unknown").Code)
+	})
+}
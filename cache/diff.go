@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"reflect"
+	"sort"
+)
+
+// dataDiff lists the IDs that differ between two versions of a dataset
+type dataDiff struct {
+	added   []uint // present only in the new data
+	removed []uint // present only in the old data
+	updated []uint // present in both with differing values
+}
+
+// diffData compares old and new data by ID using equals, or reflect.DeepEqual
+// when equals is nil. IDs are returned in ascending order.
+func diffData[T any](oldData, newData map[uint]T, equals func(a, b T) bool) dataDiff {
+	if equals == nil {
+		equals = func(a, b T) bool { return reflect.DeepEqual(a, b) }
+	}
+	var d dataDiff
+	for id, newItem := range newData {
+		oldItem, exists := oldData[id]
+		if !exists {
+			d.added = append(d.added, id)
+		} else if !equals(oldItem, newItem) {
+			d.updated = append(d.updated, id)
+		}
+	}
+	for id := range oldData {
+		if _, exists := newData[id]; !exists {
+			d.removed = append(d.removed, id)
+		}
+	}
+	sortIDs(d.added)
+	sortIDs(d.removed)
+	sortIDs(d.updated)
+	return d
+}
+
+func sortIDs(ids []uint) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+}
@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// EntryMeta describes the bookkeeping kept for a cached entry
+type EntryMeta struct {
+	LoadedAt    time.Time // when the entry was loaded or last replaced
+	LastAccess  time.Time // last Get hit, zero unless access tracking is on
+	AccessCount uint64    // number of Get hits, zero unless access tracking is on
+	Stale       bool      // served from an expired cache, see ExpiryServeStale
+}
+
+// entryMeta is the per-entry bookkeeping stored alongside data. The access
+// fields are atomic because Get updates them under the read lock.
+type entryMeta struct {
+	loadedAt    time.Time
+	lastAccess  atomic.Int64 // unix nanoseconds
+	accessCount atomic.Uint64
+	size        int64 // estimated size, tracked under a memory budget
+	readThrough bool  // loaded on its own by GetOrLoad, see entryFresh
+}
+
+// WithAccessTracking records the last access time and access count of each
+// entry on Get hits, reported through GetWithMeta
+func (cm *CacheManager[T]) WithAccessTracking(enabled bool) *CacheManager[T] {
+	cm.trackAccess = enabled
+	return cm
+}
+
+// GetWithMeta retrieves an item by ID together with its metadata. It treats
+// an expired cache the same way Get does but does not count as an access.
+func (cm *CacheManager[T]) GetWithMeta(id uint) (T, EntryMeta, bool) {
+	defer cm.notifyExpire()
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	var zero T
+	if cm.notInitialized() {
+		return zero, EntryMeta{}, false
+	}
+	stale := cm.isExpired() && !cm.entryFresh(id)
+	if stale && cm.getPolicy != ExpiryServeStale {
+		return zero, EntryMeta{}, false
+	}
+	item, exists := cm.data[id]
+	if !exists {
+		return zero, EntryMeta{}, false
+	}
+
+	meta := EntryMeta{Stale: stale}
+	if m := cm.meta[id]; m != nil {
+		meta.LoadedAt = m.loadedAt
+		meta.AccessCount = m.accessCount.Load()
+		if nanos := m.lastAccess.Load(); nanos != 0 {
+			meta.LastAccess = time.Unix(0, nanos)
+		}
+	}
+	return item, meta, true
+}
+
+// setData replaces the cached data with items loaded at the given time.
+// Callers must hold the write lock.
+func (cm *CacheManager[T]) setData(items []T, loadedAt time.Time) {
+	cm.clearData(len(items))
+	for _, item := range items {
+		cm.putItem(item, loadedAt)
+	}
+}
+
+// clearData empties the data, its metadata and the indexes, sized for
+// capacity items. Callers must hold the write lock.
+func (cm *CacheManager[T]) clearData(capacity int) {
+	cm.unindexAttached()
+	cm.data = make(map[uint]T, capacity)
+	cm.meta = make(map[uint]*entryMeta, capacity)
+	cm.resetIndexes()
+	clear(cm.negative)
+	cm.sizeBytes = 0
+	if cm.lru != nil {
+		cm.lru.reset()
+	}
+}
+
+// putItem inserts or replaces a single item loaded at the given time.
+// Callers must hold the write lock.
+func (cm *CacheManager[T]) putItem(item T, loadedAt time.Time) {
+	id := item.GetID()
+	cm.removeItem(id)
+	m := &entryMeta{loadedAt: loadedAt}
+	cm.data[id] = item
+	cm.meta[id] = m
+	cm.indexItem(id, item)
+	cm.trackInsert(id, item, m)
+}
+
+// recordAccess updates the access bookkeeping and LRU position of an entry.
+// It is safe to call under the read lock.
+func (cm *CacheManager[T]) recordAccess(id uint) {
+	if cm.lru != nil {
+		cm.trackRecency(id)
+	}
+	if !cm.trackAccess {
+		return
+	}
+	if m := cm.meta[id]; m != nil {
+		m.lastAccess.Store(time.Now().UnixNano())
+		m.accessCount.Add(1)
+	}
+}
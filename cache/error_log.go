@@ -0,0 +1,72 @@
+package cache
+
+import "time"
+
+// defaultErrorLogSize is how many refresh errors RecentErrors keeps unless
+// configured with WithErrorLog
+const defaultErrorLogSize = 10
+
+// TimestampedError is a refresh error with the time it happened
+type TimestampedError struct {
+	Time time.Time
+	Err  error
+}
+
+// errorRing keeps the most recent errors in a fixed size ring buffer
+type errorRing struct {
+	entries []TimestampedError
+	next    int // slot the next error is written to
+	full    bool
+}
+
+func newErrorRing(size int) errorRing {
+	return errorRing{entries: make([]TimestampedError, size)}
+}
+
+func (r *errorRing) add(err error, at time.Time) {
+	if len(r.entries) == 0 {
+		return
+	}
+	r.entries[r.next] = TimestampedError{Time: at, Err: err}
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// list returns the errors from oldest to newest
+func (r *errorRing) list() []TimestampedError {
+	if !r.full {
+		return append([]TimestampedError(nil), r.entries[:r.next]...)
+	}
+	result := make([]TimestampedError, 0, len(r.entries))
+	result = append(result, r.entries[r.next:]...)
+	return append(result, r.entries[:r.next]...)
+}
+
+// WithErrorLog sets how many recent refresh errors RecentErrors keeps,
+// 10 by default. A size of 0 disables the log. Changing the size drops the
+// errors logged so far.
+func (cm *CacheManager[T]) WithErrorLog(size int) *CacheManager[T] {
+	cm.executeWithLock(false, func() interface{} {
+		cm.errorLog = newErrorRing(max(0, size))
+		return nil
+	})
+	return cm
+}
+
+// RecentErrors returns the most recent refresh errors, and callback panics
+// recovered with WithPanicRecovery, oldest first
+func (cm *CacheManager[T]) RecentErrors() []TimestampedError {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.errorLog.list()
+}
+
+// recordError notes a failed refresh. Callers must hold the write lock.
+func (cm *CacheManager[T]) recordError(err error) {
+	err = cm.named(err)
+	cm.lastErr = err
+	cm.errorLog.add(err, time.Now())
+	cm.events.emit(Event{Kind: EventError, Err: err})
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheManagerRecentErrors(t *testing.T) {
+	loader := &mockUserLoader{}
+	cache := NewCacheManager[models.User](loader).WithErrorLog(3)
+	assert.Empty(t, cache.RecentErrors())
+
+	before := time.Now()
+	for i := 1; i <= 5; i++ {
+		loader.err = fmt.Errorf("failure %d", i)
+		assert.Error(t, cache.Refresh())
+	}
+
+	recent := cache.RecentErrors()
+	assert.Len(t, recent, 3, "the log is bounded")
+	for i, entry := range recent {
+		assert.EqualError(t, entry.Err, fmt.Sprintf("failure %d", i+3))
+		assert.False(t, entry.Time.Before(before))
+		if i > 0 {
+			assert.False(t, entry.Time.Before(recent[i-1].Time), "oldest first")
+		}
+	}
+
+	t.Run("successful refreshes are not logged", func(t *testing.T) {
+		loader.err = nil
+		assert.NoError(t, cache.Refresh())
+		assert.Len(t, cache.RecentErrors(), 3)
+	})
+
+	t.Run("incremental refresh errors are logged", func(t *testing.T) {
+		incremental := &mockIncrementalUserLoader{}
+		cache := NewCacheManager[models.User](incremental)
+		assert.NoError(t, cache.RefreshIncremental("updated_at"))
+		incremental.err = errors.New("incremental failure")
+		assert.Error(t, cache.RefreshIncremental("updated_at"))
+		recent := cache.RecentErrors()
+		assert.Len(t, recent, 1)
+		assert.EqualError(t, recent[0].Err, "incremental failure")
+	})
+
+	t.Run("reset clears the log", func(t *testing.T) {
+		cache.Reset()
+		assert.Empty(t, cache.RecentErrors())
+	})
+}
+
+func TestErrorRing(t *testing.T) {
+	ring := newErrorRing(2)
+	now := time.Now()
+	ring.add(errors.New("a"), now)
+	assert.Len(t, ring.list(), 1)
+	ring.add(errors.New("b"), now)
+	ring.add(errors.New("c"), now)
+
+	list := ring.list()
+	assert.Len(t, list, 2)
+	assert.EqualError(t, list[0].Err, "b")
+	assert.EqualError(t, list[1].Err, "c")
+
+	disabled := newErrorRing(0)
+	disabled.add(errors.New("a"), now)
+	assert.Empty(t, disabled.list())
+}
@@ -0,0 +1,32 @@
+package cache
+
+import "errors"
+
+// ErrCacheNotInitialized is returned by reads on a cache that has never been
+// refreshed, which usually means a missing call to Refresh
+var ErrCacheNotInitialized = errors.New("cache not initialized: call Refresh first")
+
+// ErrCacheExpired is returned by Get on a cache whose TTL has lapsed, unless
+// its expiry policy serves stale data
+var ErrCacheExpired = errors.New("cache expired")
+
+// ErrCacheClosed is returned by refreshes on a cache that has been closed
+var ErrCacheClosed = errors.New("cache closed")
+
+// ErrAutoRefreshRunning is returned by StartAutoRefresh while a previously
+// started auto-refresh is still running
+var ErrAutoRefreshRunning = errors.New("auto refresh already running")
+
+// ErrReadOnly is returned by mutations through a ReadOnlyView
+var ErrReadOnly = errors.New("cache view is read-only")
+
+// ErrNoLoader is returned by refreshes of a cache created without a loader,
+// which is filled only through Set, SetAll and Apply
+var ErrNoLoader = errors.New("cache has no loader")
+
+// ErrNoGroupLoader is returned by RefreshForeignKey when no group loader is set
+var ErrNoGroupLoader = errors.New("related cache has no group loader")
+
+// ErrCallbackPanic wraps a panic recovered from a user-supplied callback, see
+// WithPanicRecovery
+var ErrCallbackPanic = errors.New("callback panicked")
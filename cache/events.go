@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// eventBufferSize is the channel capacity of each Subscribe call
+const eventBufferSize = 64
+
+// EventKind identifies a step in the life of a cache
+type EventKind string
+
+const (
+	// EventInit is emitted once, before the Refresh event of the first load
+	EventInit EventKind = "init"
+	// EventRefresh is emitted after the dataset was reloaded or replaced
+	EventRefresh EventKind = "refresh"
+	// EventEvict is emitted when a bound evicts an entry
+	EventEvict EventKind = "evict"
+	// EventClear is emitted by Clear and Reset
+	EventClear EventKind = "clear"
+	// EventClose is emitted by the first Close, as the last event
+	EventClose EventKind = "close"
+	// EventError is emitted when a refresh fails
+	EventError EventKind = "error"
+)
+
+// Event describes a step in the life of a cache. Only the fields relevant to
+// its kind are set.
+type Event struct {
+	Kind  EventKind
+	Time  time.Time
+	Cache string // name of the emitting cache, see WithName
+
+	// Added, Removed and Updated count the changes of a full refresh or
+	// Apply; incremental refreshes leave them zero
+	Added, Removed, Updated int
+
+	ID  uint  // the evicted entry
+	Err error // the refresh error
+}
+
+// eventBus fans events out to the subscribed channels
+type eventBus struct {
+	mu     sync.Mutex
+	subs   []chan Event
+	closed bool
+	name   string // stamped on every event
+}
+
+// Subscribe returns a channel receiving the cache's events in the order
+// they happen. The channel buffers 64 events; while it is full, further
+// events are dropped for this subscriber rather than blocking the cache, so
+// consumers that must not miss events should drain it promptly. The channel
+// is closed after the Close event.
+func (cm *CacheManager[T]) Subscribe() <-chan Event {
+	b := &cm.events
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, eventBufferSize)
+	if b.closed {
+		close(ch)
+		return ch
+	}
+	b.subs = append(b.subs, ch)
+	return ch
+}
+
+// emit sends an event to every subscriber that has room for it
+func (b *eventBus) emit(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.subs) == 0 {
+		return
+	}
+	e.Time = time.Now()
+	e.Cache = b.name
+	for _, ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// setName sets the cache name stamped on every event
+func (b *eventBus) setName(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.name = name
+}
+
+// hasSubscribers reports whether emitting is worth preparing for
+func (b *eventBus) hasSubscribers() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs) > 0
+}
+
+// close emits the Close event and closes the subscribed channels
+func (b *eventBus) close() {
+	b.emit(Event{Kind: EventClose})
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, ch := range b.subs {
+		close(ch)
+	}
+	b.subs = nil
+	b.closed = true
+}
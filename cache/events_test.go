@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// drain returns the events buffered in ch
+func drain(ch <-chan Event) []Event {
+	var events []Event
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				return events
+			}
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func kinds(events []Event) []EventKind {
+	result := make([]EventKind, len(events))
+	for i, e := range events {
+		result[i] = e.Kind
+	}
+	return result
+}
+
+func TestCacheManagerSubscribe(t *testing.T) {
+	loader := &mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John"}, {ID: 2, Name: "Jane"},
+	}}
+	cache := NewCacheManager[models.User](loader)
+	events := cache.Subscribe()
+
+	assert.NoError(t, cache.Refresh())
+	cache.Clear()
+	got := drain(events)
+	assert.Equal(t, []EventKind{EventInit, EventRefresh, EventClear}, kinds(got))
+	assert.Equal(t, 2, got[1].Added)
+	assert.False(t, got[1].Time.IsZero())
+
+	t.Run("later refreshes report their changes", func(t *testing.T) {
+		assert.NoError(t, cache.Refresh())
+		loader.users = []models.User{{ID: 1, Name: "John Doe"}, {ID: 3, Name: "Bob"}}
+		assert.NoError(t, cache.Refresh())
+		got := drain(events)
+		assert.Equal(t, []EventKind{EventRefresh, EventRefresh}, kinds(got))
+		assert.Equal(t, Event{Kind: EventRefresh, Time: got[1].Time, Added: 1, Removed: 1, Updated: 1}, got[1])
+	})
+
+	t.Run("errors and evictions", func(t *testing.T) {
+		cache.WithMaxEntries(1)
+		loader.err = errors.New("database down")
+		assert.Error(t, cache.Refresh())
+		loader.err = nil
+
+		got := drain(events)
+		assert.Equal(t, []EventKind{EventEvict, EventError}, kinds(got))
+		assert.NotZero(t, got[0].ID)
+		assert.EqualError(t, got[1].Err, "database down")
+		cache.WithMaxEntries(0)
+	})
+
+	t.Run("close is the last event", func(t *testing.T) {
+		cache.Close()
+		cache.Close()
+		assert.Equal(t, []EventKind{EventClose}, kinds(drain(events)))
+		_, open := <-events
+		assert.False(t, open, "the channel is closed")
+
+		_, open = <-cache.Subscribe()
+		assert.False(t, open, "subscribing to a closed cache")
+	})
+}
+
+func TestCacheManagerSubscribeDropsForSlowConsumers(t *testing.T) {
+	cache := NewCacheManager[models.User](&mockUserLoader{}).WithMaxEntries(1)
+	events := cache.Subscribe()
+	for i := uint(1); i <= 2*eventBufferSize; i++ {
+		cache.Set(models.User{ID: i})
+	}
+
+	got := drain(events)
+	assert.Len(t, got, eventBufferSize, "events beyond the buffer are dropped")
+	assert.Equal(t, uint(1), got[0].ID, "the oldest events are kept")
+
+	cache.Set(models.User{ID: 1000})
+	assert.Len(t, drain(events), 1, "delivery resumes once drained")
+}
@@ -0,0 +1,257 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lruTracker keeps cached IDs in recency order within priority bands, most
+// recent first. It has its own mutex so Get can record hits while holding
+// only the read lock.
+type lruTracker struct {
+	mu    sync.Mutex
+	bands map[int]*list.List // priority -> IDs
+	elems map[uint]lruEntry
+}
+
+type lruEntry struct {
+	elem     *list.Element
+	priority int
+}
+
+func newLRUTracker() *lruTracker {
+	return &lruTracker{bands: make(map[int]*list.List), elems: make(map[uint]lruEntry)}
+}
+
+// touch marks id as the most recently used of its priority band, adding it
+// or moving it between bands if needed
+func (l *lruTracker) touch(id uint, priority int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.elems[id]; ok {
+		if e.priority == priority {
+			l.bands[priority].MoveToFront(e.elem)
+			return
+		}
+		l.removeLocked(id)
+	}
+	band, ok := l.bands[priority]
+	if !ok {
+		band = list.New()
+		l.bands[priority] = band
+	}
+	l.elems[id] = lruEntry{elem: band.PushFront(id), priority: priority}
+}
+
+func (l *lruTracker) remove(id uint) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.removeLocked(id)
+}
+
+func (l *lruTracker) removeLocked(id uint) {
+	e, ok := l.elems[id]
+	if !ok {
+		return
+	}
+	band := l.bands[e.priority]
+	band.Remove(e.elem)
+	if band.Len() == 0 {
+		delete(l.bands, e.priority)
+	}
+	delete(l.elems, id)
+}
+
+// oldest returns the least recently used ID of the lowest priority band
+func (l *lruTracker) oldest() (uint, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var lowest *list.List
+	lowestPriority := 0
+	for priority, band := range l.bands {
+		if lowest == nil || priority < lowestPriority {
+			lowest, lowestPriority = band, priority
+		}
+	}
+	if lowest == nil {
+		return 0, false
+	}
+	return lowest.Back().Value.(uint), true
+}
+
+func (l *lruTracker) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.bands = make(map[int]*list.List)
+	l.elems = make(map[uint]lruEntry)
+}
+
+// trackRecency marks id as used for eviction, unless it is pinned. It is
+// safe to call under the read lock.
+func (cm *CacheManager[T]) trackRecency(id uint) {
+	if _, pinned := cm.pinned[id]; pinned {
+		return
+	}
+	cm.lru.touch(id, cm.priorities[id])
+}
+
+// SetWithPriority inserts or overwrites a single item like Set and sets the
+// eviction priority of its ID. Under WithMaxEntries or WithMemoryBudget the
+// lowest priority entries are evicted first, least recently used first
+// within a priority; items stored without a priority have priority 0.
+// Priorities are kept by ID across refreshes.
+func (cm *CacheManager[T]) SetWithPriority(item T, priority int) {
+	cm.executeWithLock(false, func() interface{} {
+		id := item.GetID()
+		if priority == 0 {
+			delete(cm.priorities, id)
+		} else {
+			if cm.priorities == nil {
+				cm.priorities = make(map[uint]int)
+			}
+			cm.priorities[id] = priority
+		}
+		cm.putItem(item, time.Now())
+		return nil
+	})
+}
+
+// Pin exempts the entry with the given ID from eviction, e.g. for critical
+// reference data, until Unpin. Pins are kept by ID across refreshes. If
+// only pinned entries are left, the cache may exceed its bounds.
+func (cm *CacheManager[T]) Pin(id uint) {
+	cm.executeWithLock(false, func() interface{} {
+		if cm.pinned == nil {
+			cm.pinned = make(map[uint]struct{})
+		}
+		cm.pinned[id] = struct{}{}
+		if cm.lru != nil {
+			cm.lru.remove(id)
+		}
+		return nil
+	})
+}
+
+// Unpin makes a pinned entry evictable again
+func (cm *CacheManager[T]) Unpin(id uint) {
+	cm.executeWithLock(false, func() interface{} {
+		if _, pinned := cm.pinned[id]; !pinned {
+			return nil
+		}
+		delete(cm.pinned, id)
+		if _, exists := cm.data[id]; exists && cm.lru != nil {
+			cm.trackRecency(id)
+			cm.enforceBounds()
+		}
+		return nil
+	})
+}
+
+// WithMemoryBudget bounds the estimated size of the cached data (see
+// EstimatedSizeBytes). Whenever an insert pushes the estimate over the
+// budget, the least recently used entries are evicted until it fits again;
+// Get hits count as use. A refresh that loads more than fits keeps the most
+// recently inserted items. The estimate is approximate, so leave headroom
+// below the real memory limit. A budget of 0 disables the bound.
+func (cm *CacheManager[T]) WithMemoryBudget(bytes int64) *CacheManager[T] {
+	cm.executeWithLock(false, func() interface{} {
+		cm.memoryBudget = bytes
+		cm.rebuildBounds()
+		return nil
+	})
+	return cm
+}
+
+// WithMaxEntries caps the cache at n items with LRU eviction: an insert
+// beyond the cap, e.g. through Set, evicts the least recently used item,
+// where Get hits count as use. A refresh that loads more than n items keeps
+// the n most recently inserted ones. Evictions are counted in Stats. A cap
+// of 0 disables the bound; it can be combined with WithMemoryBudget.
+func (cm *CacheManager[T]) WithMaxEntries(n int) *CacheManager[T] {
+	cm.executeWithLock(false, func() interface{} {
+		cm.maxEntries = n
+		cm.rebuildBounds()
+		return nil
+	})
+	return cm
+}
+
+// bounded reports whether the cache has a size bound
+func (cm *CacheManager[T]) bounded() bool {
+	return cm.memoryBudget > 0 || cm.maxEntries > 0
+}
+
+// rebuildBounds sets up the tracking for the configured bounds and applies
+// them to the cached data. Callers must hold the write lock.
+func (cm *CacheManager[T]) rebuildBounds() {
+	cm.sizeBytes = 0
+	if !cm.bounded() {
+		cm.lru = nil
+		return
+	}
+	fresh := cm.lru == nil
+	if fresh {
+		cm.lru = newLRUTracker()
+	}
+	for id, item := range cm.data {
+		if m := cm.meta[id]; m != nil {
+			m.size = 0
+			if cm.memoryBudget > 0 {
+				m.size = estimateEntrySize(item)
+			}
+			cm.sizeBytes += m.size
+		}
+		if fresh {
+			cm.trackRecency(id)
+		}
+	}
+	cm.enforceBounds()
+}
+
+// trackInsert records the size and recency of an inserted item and evicts
+// entries if a bound is exceeded. Callers must hold the write lock.
+func (cm *CacheManager[T]) trackInsert(id uint, item T, m *entryMeta) {
+	if cm.lru == nil {
+		return
+	}
+	if cm.memoryBudget > 0 {
+		m.size = estimateEntrySize(item)
+		cm.sizeBytes += m.size
+	}
+	cm.trackRecency(id)
+	cm.enforceBounds()
+}
+
+// enforceBounds evicts the least recently used entries of the lowest
+// priority until the cache fits its entry cap and memory budget. Callers must hold the write lock.
+func (cm *CacheManager[T]) enforceBounds() {
+	for (cm.memoryBudget > 0 && cm.sizeBytes > cm.memoryBudget) ||
+		(cm.maxEntries > 0 && len(cm.data) > cm.maxEntries) {
+		id, ok := cm.lru.oldest()
+		if !ok {
+			return
+		}
+		cm.removeItem(id)
+		cm.stats.evictions.Add(1)
+		cm.events.emit(Event{Kind: EventEvict, ID: id})
+	}
+}
+
+// removeItem drops an item with its metadata, index entries and LRU
+// position. Callers must hold the write lock.
+func (cm *CacheManager[T]) removeItem(id uint) {
+	item, exists := cm.data[id]
+	if !exists {
+		return
+	}
+	cm.unindexItem(id, item)
+	if m := cm.meta[id]; m != nil {
+		cm.sizeBytes -= m.size
+	}
+	delete(cm.data, id)
+	delete(cm.meta, id)
+	if cm.lru != nil {
+		cm.lru.remove(id)
+	}
+}
@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheManagerMemoryBudget(t *testing.T) {
+	users := []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+		{ID: 2, Name: "Jane", Email: "jane@example.com"},
+		{ID: 3, Name: "Jack", Email: "jack@example.com"},
+		{ID: 4, Name: "Jill", Email: "jill@example.com"},
+		{ID: 5, Name: "Joan", Email: "joan@example.com"},
+	}
+	entrySize := estimateEntrySize(users[0]) // all users have the same size
+	budget := 3*entrySize + entrySize/2
+
+	loader := &mockUserLoader{users: users}
+	cache := NewCacheManager[models.User](loader).WithMemoryBudget(budget)
+
+	keys := func() []uint {
+		ids := cache.Keys()
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		return ids
+	}
+
+	t.Run("refresh keeps the most recently inserted items", func(t *testing.T) {
+		assert.NoError(t, cache.Refresh())
+		assert.Equal(t, []uint{3, 4, 5}, keys())
+		assert.LessOrEqual(t, cache.EstimatedSizeBytes(), budget)
+		assert.Equal(t, uint64(2), cache.Stats().Evictions)
+	})
+
+	t.Run("get hits protect entries from eviction", func(t *testing.T) {
+		_, err := cache.Get(3)
+		assert.NoError(t, err)
+
+		other := NewCacheManager[models.User](&mockUserLoader{users: []models.User{
+			{ID: 6, Name: "Jude", Email: "jude@example.com"},
+		}})
+		assert.NoError(t, other.Refresh())
+		cache.Merge(other, MergeOverwrite)
+		assert.Equal(t, []uint{3, 5, 6}, keys(), "4 was the least recently used")
+		assert.LessOrEqual(t, cache.EstimatedSizeBytes(), budget)
+	})
+
+	t.Run("estimate matches an unbounded cache", func(t *testing.T) {
+		unbounded := NewCacheManager[models.User](&mockUserLoader{users: users[:3]})
+		assert.NoError(t, unbounded.Refresh())
+		assert.Equal(t, 3*entrySize, unbounded.EstimatedSizeBytes())
+	})
+}
+
+func TestCacheManagerMaxEntries(t *testing.T) {
+	users := []models.User{
+		{ID: 1, Name: "John"}, {ID: 2, Name: "Jane"}, {ID: 3, Name: "Jack"},
+		{ID: 4, Name: "Jill"}, {ID: 5, Name: "Joan"},
+	}
+	cache := NewCacheManager[models.User](&mockUserLoader{users: users}).WithMaxEntries(3)
+
+	keys := func() []uint {
+		ids := cache.Keys()
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		return ids
+	}
+
+	t.Run("refresh keeps the most recently inserted items", func(t *testing.T) {
+		assert.NoError(t, cache.Refresh())
+		assert.Equal(t, []uint{3, 4, 5}, keys())
+		assert.Equal(t, uint64(2), cache.Stats().Evictions)
+	})
+
+	t.Run("set evicts the least recently used item", func(t *testing.T) {
+		_, err := cache.Get(3)
+		assert.NoError(t, err)
+		cache.Set(models.User{ID: 6, Name: "Jeff"})
+		assert.Equal(t, []uint{3, 5, 6}, keys())
+		assert.Equal(t, uint64(3), cache.Stats().Evictions)
+	})
+
+	t.Run("overwrites do not evict", func(t *testing.T) {
+		cache.Set(models.User{ID: 6, Name: "Jeff B"})
+		assert.Equal(t, []uint{3, 5, 6}, keys())
+		assert.Equal(t, uint64(3), cache.Stats().Evictions)
+	})
+
+	t.Run("lowering the cap evicts immediately", func(t *testing.T) {
+		cache.WithMaxEntries(1)
+		assert.Equal(t, []uint{6}, keys())
+	})
+
+	t.Run("a cap of 0 disables the bound", func(t *testing.T) {
+		cache.WithMaxEntries(0)
+		assert.NoError(t, cache.Refresh())
+		assert.Len(t, cache.Keys(), 5)
+		assert.Nil(t, cache.lru)
+	})
+}
+
+func TestEstimateEntrySize(t *testing.T) {
+	short := estimateEntrySize(models.User{Name: "a"})
+	long := estimateEntrySize(models.User{Name: "a very long name indeed"})
+	assert.Equal(t, int64(len("a very long name indeed")-1), long-short)
+
+	withOrders := estimateEntrySize(models.UserV2{Orders: make([]models.Order, 2)})
+	without := estimateEntrySize(models.UserV2{})
+	assert.Greater(t, withOrders, without, "referenced slices are counted")
+}
+
+func TestCacheManagerEvictionPriority(t *testing.T) {
+	users := []models.User{
+		{ID: 1, Name: "John"}, {ID: 2, Name: "Jane"}, {ID: 3, Name: "Jack"},
+	}
+	cache := NewCacheManager[models.User](&mockUserLoader{users: users}).WithMaxEntries(3)
+	assert.NoError(t, cache.Refresh())
+
+	keys := func() []uint {
+		ids := cache.Keys()
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		return ids
+	}
+
+	t.Run("pinned and high priority entries survive eviction", func(t *testing.T) {
+		cache.Pin(1)
+		cache.SetWithPriority(models.User{ID: 2, Name: "Jane"}, 10)
+		cache.Set(models.User{ID: 4, Name: "Jill"})
+		cache.Set(models.User{ID: 5, Name: "Joan"})
+		assert.Equal(t, []uint{1, 2, 5}, keys())
+	})
+
+	t.Run("lru order applies within a priority", func(t *testing.T) {
+		cache.SetWithPriority(models.User{ID: 6, Name: "Jeff"}, 10)
+		assert.Equal(t, []uint{1, 2, 6}, keys())
+		_, err := cache.Get(2)
+		assert.NoError(t, err)
+		cache.SetWithPriority(models.User{ID: 7, Name: "Jean"}, 10)
+		assert.Equal(t, []uint{1, 2, 7}, keys())
+	})
+
+	t.Run("new entries are evicted when the rest are pinned", func(t *testing.T) {
+		cache.Pin(2)
+		cache.Pin(7)
+		cache.Set(models.User{ID: 8, Name: "Jude"})
+		assert.Equal(t, []uint{1, 2, 7}, keys())
+	})
+
+	t.Run("unpinned entries become evictable", func(t *testing.T) {
+		cache.Unpin(1)
+		cache.Unpin(2)
+		cache.Set(models.User{ID: 9, Name: "Joy"})
+		assert.Equal(t, []uint{2, 7, 9}, keys())
+	})
+}
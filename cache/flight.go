@@ -0,0 +1,51 @@
+package cache
+
+import "sync"
+
+// flightCall is a refresh in flight
+type flightCall struct {
+	done chan struct{}
+	err  error
+}
+
+// refreshFlight deduplicates concurrent refreshes in the spirit of
+// golang.org/x/sync/singleflight with a single constant key: callers that
+// arrive while a refresh is in flight wait for it and share its error
+// instead of loading again.
+type refreshFlight struct {
+	mu   sync.Mutex
+	call *flightCall
+}
+
+// do runs fn, or waits for the fn already in flight. leader reports whether
+// this caller ran fn.
+func (f *refreshFlight) do(fn func() error) (leader bool, err error) {
+	f.mu.Lock()
+	if c := f.call; c != nil {
+		f.mu.Unlock()
+		<-c.done
+		return false, c.err
+	}
+	c := &flightCall{done: make(chan struct{})}
+	f.call = c
+	f.mu.Unlock()
+
+	defer func() {
+		f.mu.Lock()
+		f.call = nil
+		f.mu.Unlock()
+		close(c.done)
+	}()
+	c.err = fn()
+	return true, c.err
+}
+
+// wait blocks until the fn in flight, if any, has returned
+func (f *refreshFlight) wait() {
+	f.mu.Lock()
+	c := f.call
+	f.mu.Unlock()
+	if c != nil {
+		<-c.done
+	}
+}
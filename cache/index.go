@@ -0,0 +1,164 @@
+package cache
+
+import (
+	"reflect"
+	"strings"
+)
+
+// WithIndexes declares named secondary indexes, each mapping an item to the
+// string key it is looked up by, e.g. "email" or a name prefix. The indexes
+// are maintained under the write lock together with the data, so a
+// QueryByIndex never sees an index that disagrees with it. Query answers a
+// StringFieldCondition from an index only if its FieldName names the index
+// and its FieldExtractor is the func declared for it. Declaring indexes
+// replaces any declared before.
+func (cm *CacheManager[T]) WithIndexes(indexes map[string]func(T) string) *CacheManager[T] {
+	cm.executeWithLock(false, func() interface{} {
+		cm.indexFuncs = indexes
+		cm.indexFields = make(map[string]uintptr, len(indexes))
+		for name, key := range indexes {
+			cm.indexFields[name] = funcPointer(key)
+		}
+		cm.rebuildIndexes()
+		return nil
+	})
+	return cm
+}
+
+// QueryByIndex returns the items whose key in the named index equals value.
+// The lookup is O(1) plus the size of the result, whose order is
+// nondeterministic. An undeclared index yields nil.
+func (cm *CacheManager[T]) QueryByIndex(name, value string) []T {
+	result := cm.executeWithLock(true, func() interface{} {
+		if !cm.listAllowed() {
+			return unavailableList[T](cm)
+		}
+		index, ok := cm.indexes[name]
+		if !ok {
+			return []T(nil)
+		}
+		ids := index[value]
+		items := make([]T, 0, len(ids))
+		for id := range ids {
+			items = append(items, cm.data[id])
+		}
+		return items
+	})
+	return result.([]T)
+}
+
+// resetIndexes empties every declared index. Callers must hold the write lock.
+func (cm *CacheManager[T]) resetIndexes() {
+	if len(cm.indexFuncs) == 0 {
+		cm.indexes = nil
+		return
+	}
+	cm.indexes = make(map[string]map[string]map[uint]struct{}, len(cm.indexFuncs))
+	for name := range cm.indexFuncs {
+		cm.indexes[name] = make(map[string]map[uint]struct{})
+	}
+}
+
+// rebuildIndexes recomputes every declared index from the data. Callers
+// must hold the write lock.
+func (cm *CacheManager[T]) rebuildIndexes() {
+	cm.resetIndexes()
+	for id, item := range cm.data {
+		cm.indexItem(id, item)
+	}
+}
+
+// indexItem adds an item to every declared and attached index. Callers must hold the
+// write lock.
+func (cm *CacheManager[T]) indexItem(id uint, item T) {
+	for _, index := range cm.attached {
+		cm.callLocked("index", func() { index.Add(id, item) })
+	}
+	for name, key := range cm.indexFuncs {
+		var value string
+		if !cm.callLocked("index key func", func() { value = key(item) }) {
+			continue
+		}
+		ids, ok := cm.indexes[name][value]
+		if !ok {
+			ids = make(map[uint]struct{})
+			cm.indexes[name][value] = ids
+		}
+		ids[id] = struct{}{}
+	}
+}
+
+// unindexItem removes an item from every declared and attached index. Callers must hold
+// the write lock.
+func (cm *CacheManager[T]) unindexItem(id uint, item T) {
+	for _, index := range cm.attached {
+		cm.callLocked("index", func() { index.Remove(id, item) })
+	}
+	for name, key := range cm.indexFuncs {
+		var value string
+		if !cm.callLocked("index key func", func() { value = key(item) }) {
+			continue
+		}
+		ids := cm.indexes[name][value]
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(cm.indexes[name], value)
+		}
+	}
+}
+
+// indexCandidates returns the IDs a condition can match when it, or a child
+// of an "and", is a string condition on an indexed field. The candidates
+// still have to be matched against the whole condition. ok is false if the
+// condition needs a full scan. Callers must hold the lock.
+func (cm *CacheManager[T]) indexCandidates(condition QueryCondition[T]) (ids map[uint]struct{}, ok bool) {
+	switch c := condition.(type) {
+	case StringFieldCondition[T]:
+		return cm.stringCandidates(c)
+	case *StringFieldCondition[T]:
+		return cm.stringCandidates(*c)
+	case CompositeCondition[T]:
+		if c.Operation != "and" {
+			return nil, false
+		}
+		for _, child := range c.Conditions {
+			if ids, ok := cm.indexCandidates(child); ok {
+				return ids, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// stringCandidates looks a condition up in the index named by its FieldName.
+// The index holds the keys of its own func, so it only answers conditions
+// extracting with that very func; any other extractor may disagree with the
+// keys, e.g. by case, and needs a full scan.
+func (cm *CacheManager[T]) stringCandidates(c StringFieldCondition[T]) (map[uint]struct{}, bool) {
+	index, ok := cm.indexes[c.FieldName]
+	if c.FieldName == "" || c.CaseInsensitive || !ok || c.FieldExtractor == nil ||
+		funcPointer(c.FieldExtractor) != cm.indexFields[c.FieldName] {
+		return nil, false
+	}
+	switch c.Operation {
+	case "eq":
+		return index[c.Value], true
+	case "startsWith":
+		ids := make(map[uint]struct{})
+		for value, bucket := range index {
+			if strings.HasPrefix(value, c.Value) {
+				for id := range bucket {
+					ids[id] = struct{}{}
+				}
+			}
+		}
+		return ids, true
+	}
+	return nil, false
+}
+
+// funcPointer identifies a func by its code pointer. Closures of one func
+// literal share it, so they count as the same func.
+func funcPointer[T any](fn func(T) string) uintptr {
+	return reflect.ValueOf(fn).Pointer()
+}
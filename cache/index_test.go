@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheManagerIndexes(t *testing.T) {
+	loader := &mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+		{ID: 2, Name: "Johanna", Email: "johanna@example.com"},
+		{ID: 3, Name: "Jane", Email: "jane@example.com"},
+	}}
+	cache := NewCacheManager[models.User](loader).WithIndexes(map[string]func(models.User) string{
+		"email": func(u models.User) string { return u.Email },
+		"name3": func(u models.User) string { return strings.ToLower(u.Name[:min(3, len(u.Name))]) },
+	})
+	assert.NoError(t, cache.Refresh())
+
+	ids := func(users []models.User) []uint {
+		result := make([]uint, len(users))
+		for i, user := range users {
+			result[i] = user.ID
+		}
+		sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+		return result
+	}
+
+	t.Run("looks up each index", func(t *testing.T) {
+		assert.Equal(t, []uint{3}, ids(cache.QueryByIndex("email", "jane@example.com")))
+		assert.Equal(t, []uint{1, 2}, ids(cache.QueryByIndex("name3", "joh")))
+		assert.Empty(t, cache.QueryByIndex("email", "nobody@example.com"))
+		assert.Nil(t, cache.QueryByIndex("phone", "123"))
+	})
+
+	t.Run("stays consistent across refreshes", func(t *testing.T) {
+		loader.users = []models.User{
+			{ID: 1, Name: "Jack", Email: "jack@example.com"},
+			{ID: 3, Name: "Jane", Email: "jane@example.com"},
+			{ID: 4, Name: "Johnny", Email: "johnny@example.com"},
+		}
+		assert.NoError(t, cache.Refresh())
+
+		assert.Empty(t, cache.QueryByIndex("email", "john@example.com"))
+		assert.Equal(t, []uint{1}, ids(cache.QueryByIndex("email", "jack@example.com")))
+		assert.Equal(t, []uint{4}, ids(cache.QueryByIndex("name3", "joh")))
+		assert.Equal(t, []uint{1}, ids(cache.QueryByIndex("name3", "jac")))
+	})
+
+	t.Run("follows single item updates", func(t *testing.T) {
+		cache.Patch(3, func(u *models.User) { u.Email = "jane.doe@example.com" })
+		assert.Empty(t, cache.QueryByIndex("email", "jane@example.com"))
+		assert.Equal(t, []uint{3}, ids(cache.QueryByIndex("email", "jane.doe@example.com")))
+
+		cache.Compact()
+		assert.Equal(t, []uint{3}, ids(cache.QueryByIndex("email", "jane.doe@example.com")))
+
+		cache.Clear()
+		assert.Empty(t, cache.QueryByIndex("email", "jane.doe@example.com"))
+	})
+}
+
+func TestCacheManagerQueryUsesIndex(t *testing.T) {
+	var matches atomic.Int32
+	email := func(u models.User) string {
+		matches.Add(1)
+		return u.Email
+	}
+	cache := NewSyntheticCacheManager(1000, syntheticUser).WithIndexes(map[string]func(models.User) string{
+		"email": email,
+		"name":  func(u models.User) string { return strings.ToLower(u.Name) },
+	}).WithDeterministicOrder(func(a, b models.User) bool { return a.ID < b.ID })
+
+	t.Run("equality is answered from the index", func(t *testing.T) {
+		matches.Store(0)
+		got := cache.Query(StringFieldCondition[models.User]{
+			FieldExtractor: email, FieldName: "email", Value: "user-42@example2.com", Operation: "eq",
+		})
+		assert.Equal(t, []uint{42}, ids(got))
+		assert.Equal(t, int32(1), matches.Load())
+	})
+
+	t.Run("prefixes and conjunctions use the index", func(t *testing.T) {
+		matches.Store(0)
+		got := cache.Query(StringFieldCondition[models.User]{
+			FieldExtractor: email, FieldName: "email", Value: "user-99", Operation: "startsWith",
+		})
+		assert.Equal(t, []uint{99, 990, 991, 992, 993, 994, 995, 996, 997, 998, 999}, ids(got))
+		assert.Equal(t, int32(len(got)), matches.Load())
+
+		matches.Store(0)
+		got = cache.Query(And[models.User](
+			NumberFieldCondition[models.User, uint]{FieldExtractor: func(u models.User) uint { return u.ID }, Value: 995, Operation: "gt"},
+			StringFieldCondition[models.User]{FieldExtractor: email, FieldName: "email", Value: "user-99", Operation: "startsWith"},
+		))
+		assert.Equal(t, []uint{996, 997, 998, 999}, ids(got))
+		assert.Equal(t, int32(4), matches.Load(), "candidates failing the ID condition skip the string condition")
+	})
+
+	t.Run("other conditions scan", func(t *testing.T) {
+		matches.Store(0)
+		got := cache.Query(StringFieldCondition[models.User]{
+			FieldExtractor: email, FieldName: "email", Value: "@example2.com", Operation: "endsWith",
+		})
+		assert.Len(t, got, 100)
+		assert.Equal(t, int32(1000), matches.Load())
+	})
+
+	t.Run("an extractor other than the index func scans", func(t *testing.T) {
+		cache.Set(models.User{ID: 1001, Name: "Jane"})
+		var scanned atomic.Int32
+		got := cache.Query(StringFieldCondition[models.User]{
+			FieldExtractor: func(u models.User) string {
+				scanned.Add(1)
+				return u.Name
+			},
+			FieldName: "name", Value: "Jane", Operation: "eq",
+		})
+		assert.Equal(t, []uint{1001}, ids(got), "the lowercased index key would have missed it")
+		assert.Equal(t, int32(1001), scanned.Load())
+	})
+}
@@ -1,5 +1,10 @@
 package cache
 
+import (
+	"context"
+	"time"
+)
+
 // Identifiable represents an entity that has an ID
 type Identifiable interface {
 	GetID() uint
@@ -16,6 +21,34 @@ type DataLoader[T any] interface {
 	Load() ([]T, error)
 }
 
+// ContextLoader is a DataLoader whose loads can be bound to a context.
+// RefreshContext passes its ctx to loaders implementing it.
+type ContextLoader[T any] interface {
+	DataLoader[T]
+	LoadCtx(ctx context.Context) ([]T, error)
+}
+
+// IncrementalLoader is a DataLoader that can load only the items changed
+// since a point in time
+type IncrementalLoader[T any] interface {
+	DataLoader[T]
+	LoadSince(column string, since time.Time) ([]T, error)
+}
+
+// BatchLoader is a DataLoader that can load a set of items by ID, used by
+// the read-through GetOrLoad. IDs that do not exist are left out of the result.
+type BatchLoader[T any] interface {
+	DataLoader[T]
+	LoadMany(ids []uint) ([]T, error)
+}
+
+// SingleLoader is a DataLoader that can load one item by ID, used by the
+// lazy loading of WithLazyLoad. found is false if the ID does not exist.
+type SingleLoader[T any] interface {
+	DataLoader[T]
+	LoadOne(id uint) (item T, found bool, err error)
+}
+
 // QueryCondition defines the interface for query conditions
 type QueryCondition[T any] interface {
 	Match(item T) bool
@@ -0,0 +1,178 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// KeyIdentifiable represents an entity whose ID is of any comparable type,
+// e.g. a string UUID
+type KeyIdentifiable[K comparable] interface {
+	GetID() K
+}
+
+// KeyedCacheManager is a cache for entities whose primary key is not a uint,
+// e.g. tables keyed by string UUIDs. It is deliberately a reduced subset of
+// CacheManager: TTL-based expiry, Get, GetAll, Keys, Query, Refresh and
+// single-item writes. Before the first Refresh and after the TTL it behaves
+// like a CacheManager with the default policies: Get fails with
+// ErrCacheNotInitialized or ErrCacheExpired and the list reads return nil.
+// A cache without a loader is filled by Set and is never uninitialized. It
+// does not support expiry policies or funcs, hooks, events, indexes,
+// deterministic order, lazy or read-through loading, size bounds, eviction,
+// names, stats, panic recovery, refresh deduplication or auto-refresh.
+// Models with uint IDs keep using CacheManager.
+type KeyedCacheManager[K comparable, T KeyIdentifiable[K]] struct {
+	data      map[K]T
+	mu        sync.RWMutex
+	loader    DataLoader[T]
+	ttl       time.Duration
+	lastFetch time.Time
+}
+
+// NewKeyedCacheManager creates a new keyed cache manager instance with a
+// default TTL of permanent if not set
+func NewKeyedCacheManager[K comparable, T KeyIdentifiable[K]](loader DataLoader[T]) *KeyedCacheManager[K, T] {
+	return &KeyedCacheManager[K, T]{
+		data:   make(map[K]T),
+		loader: loader,
+	}
+}
+
+// WithTTL sets the time-to-live for the cache
+func (kc *KeyedCacheManager[K, T]) WithTTL(ttl time.Duration) *KeyedCacheManager[K, T] {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+
+	kc.ttl = ttl
+	return kc
+}
+
+// Get retrieves an item by ID
+func (kc *KeyedCacheManager[K, T]) Get(id K) (T, error) {
+	kc.mu.RLock()
+	defer kc.mu.RUnlock()
+
+	var zero T
+	if kc.notInitialized() {
+		return zero, ErrCacheNotInitialized
+	}
+	if kc.isExpired() {
+		return zero, ErrCacheExpired
+	}
+	item, exists := kc.data[id]
+	if !exists {
+		return zero, fmt.Errorf("item with ID %v not found", id)
+	}
+	return item, nil
+}
+
+// GetAll returns all items in the cache
+func (kc *KeyedCacheManager[K, T]) GetAll() []T {
+	kc.mu.RLock()
+	defer kc.mu.RUnlock()
+
+	if !kc.listAllowed() {
+		return nil
+	}
+	items := make([]T, 0, len(kc.data))
+	for _, item := range kc.data {
+		items = append(items, item)
+	}
+	return items
+}
+
+// Keys returns the IDs of all items in the cache. The order is
+// nondeterministic.
+func (kc *KeyedCacheManager[K, T]) Keys() []K {
+	kc.mu.RLock()
+	defer kc.mu.RUnlock()
+
+	if !kc.listAllowed() {
+		return nil
+	}
+	keys := make([]K, 0, len(kc.data))
+	for id := range kc.data {
+		keys = append(keys, id)
+	}
+	return keys
+}
+
+// Query returns items that match the given condition
+func (kc *KeyedCacheManager[K, T]) Query(condition QueryCondition[T]) []T {
+	kc.mu.RLock()
+	defer kc.mu.RUnlock()
+
+	if !kc.listAllowed() {
+		return nil
+	}
+	result := make([]T, 0)
+	for _, item := range kc.data {
+		if condition.Match(item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// Refresh reloads the cache data
+func (kc *KeyedCacheManager[K, T]) Refresh() error {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+
+	if kc.loader == nil {
+		return ErrNoLoader
+	}
+	items, err := kc.loader.Load()
+	if err != nil {
+		return err
+	}
+
+	data := make(map[K]T, len(items))
+	for _, item := range items {
+		data[item.GetID()] = item
+	}
+	kc.data = data
+	kc.lastFetch = time.Now()
+	return nil
+}
+
+// Set inserts or overwrites a single item. It leaves the TTL untouched.
+func (kc *KeyedCacheManager[K, T]) Set(item T) {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+
+	kc.data[item.GetID()] = item
+}
+
+// Delete removes a single item if it is cached
+func (kc *KeyedCacheManager[K, T]) Delete(id K) {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+
+	delete(kc.data, id)
+}
+
+// Clear removes all items from the cache
+func (kc *KeyedCacheManager[K, T]) Clear() {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+
+	kc.data = make(map[K]T)
+}
+
+// notInitialized reports whether the cache still waits for its first
+// refresh, like CacheManager.notInitialized
+func (kc *KeyedCacheManager[K, T]) notInitialized() bool {
+	return kc.loader != nil && kc.lastFetch.IsZero()
+}
+
+// listAllowed reports whether list reads may serve the cached data
+func (kc *KeyedCacheManager[K, T]) listAllowed() bool {
+	return !kc.notInitialized() && !kc.isExpired()
+}
+
+func (kc *KeyedCacheManager[K, T]) isExpired() bool {
+	return kc.ttl > 0 && !kc.lastFetch.IsZero() && time.Since(kc.lastFetch) > kc.ttl
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// product is keyed by a string UUID
+type product struct {
+	ID   string
+	Name string
+}
+
+func (p product) GetID() string { return p.ID }
+
+func TestKeyedCacheManager(t *testing.T) {
+	loader := SliceLoader[product]{
+		{ID: "6f1c2a9e-0b7d-4d1e-9a52-3c8f0e1b2d44", Name: "Keyboard"},
+		{ID: "b3e8d4f0-91a6-4c3b-8e2f-7d5a6c9b0e13", Name: "Mouse"},
+	}
+	cache := NewKeyedCacheManager[string, product](loader).WithTTL(time.Minute)
+
+	_, err := cache.Get(loader[0].ID)
+	assert.ErrorIs(t, err, ErrCacheNotInitialized)
+	assert.Nil(t, cache.GetAll(), "list reads match CacheManager before the first refresh")
+	assert.Nil(t, cache.Keys())
+	assert.Nil(t, cache.Query(And[product]()))
+
+	assert.NoError(t, cache.Refresh())
+	item, err := cache.Get(loader[1].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Mouse", item.Name)
+	_, err = cache.Get("missing")
+	assert.Error(t, err)
+	assert.ElementsMatch(t, []string{loader[0].ID, loader[1].ID}, cache.Keys())
+
+	keyboards := cache.Query(StringFieldCondition[product]{
+		FieldExtractor: func(p product) string { return p.Name },
+		Value:          "Key",
+		Operation:      "startsWith",
+	})
+	assert.Equal(t, []product{loader[0]}, keyboards)
+
+	t.Run("single writes", func(t *testing.T) {
+		cache.Set(product{ID: "c0ffee00-0000-4000-8000-000000000000", Name: "Monitor"})
+		cache.Delete(loader[0].ID)
+		assert.Len(t, cache.GetAll(), 2)
+		item, err := cache.Get("c0ffee00-0000-4000-8000-000000000000")
+		assert.NoError(t, err)
+		assert.Equal(t, "Monitor", item.Name)
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		cache.lastFetch = time.Now().Add(-2 * time.Minute)
+		_, err := cache.Get(loader[1].ID)
+		assert.ErrorIs(t, err, ErrCacheExpired)
+		assert.Nil(t, cache.GetAll())
+	})
+}
+
+func TestKeyedCacheManagerWithoutLoader(t *testing.T) {
+	cache := NewKeyedCacheManager[string, product](nil)
+	assert.Empty(t, cache.GetAll())
+	assert.NotNil(t, cache.GetAll(), "a pushed cache is never uninitialized")
+
+	cache.Set(product{ID: "a", Name: "Keyboard"})
+	item, err := cache.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "Keyboard", item.Name)
+	assert.Equal(t, []string{"a"}, cache.Keys())
+	assert.ErrorIs(t, cache.Refresh(), ErrNoLoader)
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// WithLazyLoad turns the cache into a cache-aside one: a Get miss loads the
+// item through the loader's LoadOne, caches it and returns it, so the cache
+// is filled on demand instead of by a bulk Refresh, which becomes optional.
+// In this mode every entry expires on its own, a TTL after it was loaded.
+// The loader must implement SingleLoader.
+func (cm *CacheManager[T]) WithLazyLoad(enabled bool) *CacheManager[T] {
+	cm.lazy = enabled
+	return cm
+}
+
+// WithNegativeCacheTTL makes lazy loading remember IDs that LoadOne did not
+// find for ttl, so repeated misses for them do not reach the loader. A ttl
+// of 0, the default, disables negative caching.
+func (cm *CacheManager[T]) WithNegativeCacheTTL(ttl time.Duration) *CacheManager[T] {
+	cm.executeWithLock(false, func() interface{} {
+		cm.negativeTTL = ttl
+		cm.negative = make(map[uint]time.Time)
+		return nil
+	})
+	return cm
+}
+
+// getLazy implements Get for lazy loading
+func (cm *CacheManager[T]) getLazy(id uint) (T, error) {
+	var zero T
+	item, cached, notFound := cm.lookupLazy(id)
+	cm.stats.record(cached)
+	if cached {
+		return item, nil
+	}
+	if notFound {
+		return zero, cm.named(fmt.Errorf("item with ID %d not found", id))
+	}
+
+	cm.mu.RLock()
+	loader, ok := cm.loader.(SingleLoader[T])
+	cm.mu.RUnlock()
+	if !ok {
+		return zero, cm.named(fmt.Errorf("loader does not support loading single items"))
+	}
+	var found bool
+	var err error
+	if perr := cm.recoverLoad(func() { item, found, err = loader.LoadOne(id) }); perr != nil {
+		err = perr
+	}
+	if err != nil {
+		return zero, cm.named(fmt.Errorf("failed to load item with ID %d: %w", id, err))
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if !found {
+		if cm.negativeTTL > 0 {
+			cm.negative[id] = time.Now()
+		}
+		return zero, cm.named(fmt.Errorf("item with ID %d not found", id))
+	}
+	delete(cm.negative, id)
+	cm.putItem(item, time.Now())
+	return item, nil
+}
+
+// lookupLazy returns a cached item that is still fresh, or reports whether
+// the ID is negatively cached
+func (cm *CacheManager[T]) lookupLazy(id uint) (item T, cached, notFound bool) {
+	defer cm.notifyExpire()
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	cm.isExpired() // latches the expiry predicate and arms OnExpire
+	if m := cm.meta[id]; m != nil && cm.lazyFresh(m.loadedAt) {
+		cm.recordAccess(id)
+		return cm.data[id], true, false
+	}
+	if at, ok := cm.negative[id]; ok && time.Since(at) <= cm.negativeTTL {
+		return item, false, true
+	}
+	return item, false, false
+}
+
+// lazyFresh reports whether an entry loaded at the given time may be served
+func (cm *CacheManager[T]) lazyFresh(loadedAt time.Time) bool {
+	if cm.getPolicy == ExpiryServeStale {
+		return true
+	}
+	return !cm.signaled.Load() && (cm.ttl <= 0 || time.Since(loadedAt) <= cm.ttl)
+}
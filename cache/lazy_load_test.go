@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockSingleUserLoader serves LoadOne from a map and counts the calls
+type mockSingleUserLoader struct {
+	mockUserLoader
+	byID  map[uint]models.User
+	calls int
+}
+
+func (m *mockSingleUserLoader) LoadOne(id uint) (models.User, bool, error) {
+	m.calls++
+	if m.err != nil {
+		return models.User{}, false, m.err
+	}
+	user, found := m.byID[id]
+	return user, found, nil
+}
+
+func TestCacheManagerLazyLoad(t *testing.T) {
+	loader := &mockSingleUserLoader{byID: map[uint]models.User{
+		1: {ID: 1, Name: "John"},
+		2: {ID: 2, Name: "Jane"},
+	}}
+	cache := NewCacheManager[models.User](loader).WithTTL(time.Minute).WithLazyLoad(true)
+
+	user, err := cache.Get(1)
+	assert.NoError(t, err, "no Refresh is needed")
+	assert.Equal(t, "John", user.Name)
+	_, err = cache.Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, loader.calls, "the second Get is a hit")
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1}, cache.Stats())
+
+	t.Run("misses are not cached by default", func(t *testing.T) {
+		_, err := cache.Get(9)
+		assert.Error(t, err)
+		_, err = cache.Get(9)
+		assert.Error(t, err)
+		assert.Equal(t, 3, loader.calls)
+	})
+
+	t.Run("entries expire a TTL after their load", func(t *testing.T) {
+		cache.meta[1].loadedAt = time.Now().Add(-2 * time.Minute)
+		loader.byID[1] = models.User{ID: 1, Name: "John Doe"}
+		user, err := cache.Get(1)
+		assert.NoError(t, err)
+		assert.Equal(t, "John Doe", user.Name)
+		assert.Equal(t, 4, loader.calls)
+	})
+
+	t.Run("loader errors are returned", func(t *testing.T) {
+		loader.err = errors.New("database down")
+		_, err := cache.Get(2)
+		assert.ErrorIs(t, err, loader.err)
+		loader.err = nil
+	})
+
+	t.Run("requires a SingleLoader", func(t *testing.T) {
+		cache := NewCacheManager[models.User](&mockUserLoader{}).WithLazyLoad(true)
+		_, err := cache.Get(1)
+		assert.ErrorContains(t, err, "does not support")
+	})
+}
+
+func TestCacheManagerNegativeCacheTTL(t *testing.T) {
+	loader := &mockSingleUserLoader{byID: map[uint]models.User{1: {ID: 1, Name: "John"}}}
+	cache := NewCacheManager[models.User](loader).WithLazyLoad(true).WithNegativeCacheTTL(time.Minute)
+
+	for i := 0; i < 3; i++ {
+		_, err := cache.Get(9)
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 1, loader.calls, "not found IDs are remembered")
+
+	cache.negative[9] = time.Now().Add(-2 * time.Minute)
+	loader.byID[9] = models.User{ID: 9, Name: "Late"}
+	user, err := cache.Get(9)
+	assert.NoError(t, err, "negative entries expire")
+	assert.Equal(t, "Late", user.Name)
+	assert.Equal(t, 2, loader.calls)
+}
+
+// namedSingleUserLoader loads every ID as a user with a fixed name
+type namedSingleUserLoader struct {
+	mockUserLoader
+	name string
+}
+
+func (l namedSingleUserLoader) LoadOne(id uint) (models.User, bool, error) {
+	return models.User{ID: id, Name: l.name}, true, nil
+}
+
+func TestCacheManagerLazyLoadSetLoader(t *testing.T) {
+	cache := NewCacheManager[models.User](&namedSingleUserLoader{name: "old"}).WithLazyLoad(true)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			cache.SetLoader(&namedSingleUserLoader{name: "new"})
+			runtime.Gosched()
+		}
+	}()
+	var wg sync.WaitGroup
+	for g := uint(0); g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := g*1000 + 1; id <= (g+1)*1000; id++ {
+				_, err := cache.Get(id)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+	<-done
+
+	user, err := cache.Get(4001)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", user.Name)
+}
@@ -0,0 +1,50 @@
+package cache
+
+import "fmt"
+
+// WithName names the cache, e.g. after the table it holds, so that in a
+// process running many caches its errors, log lines, stats and events say
+// which cache they come from. Errors are wrapped as `cache "name": ...` and
+// still match their sentinel with errors.Is.
+func (cm *CacheManager[T]) WithName(name string) *CacheManager[T] {
+	cm.name = name
+	cm.events.setName(name)
+	return cm
+}
+
+// Name returns the name set with WithName
+func (cm *CacheManager[T]) Name() string {
+	return cm.name
+}
+
+// named wraps err with the cache's name, if it has one
+func (cm *CacheManager[T]) named(err error) error {
+	if err == nil || cm.name == "" {
+		return err
+	}
+	return fmt.Errorf("cache %q: %w", cm.name, err)
+}
+
+// logf writes a log line prefixed with the cache's name, if it has one
+func (cm *CacheManager[T]) logf(format string, args ...any) {
+	if cm.name != "" {
+		format = "cache %q: " + format
+		args = append([]any{cm.name}, args...)
+	}
+	cm.logger.Printf(format, args...)
+}
+
+// WithName names the cache so its refresh errors say which cache they come
+// from, like CacheManager.WithName
+func (rcm *RelatedCacheManager[T]) WithName(name string) *RelatedCacheManager[T] {
+	rcm.name = name
+	return rcm
+}
+
+// named wraps err with the cache's name, if it has one
+func (rcm *RelatedCacheManager[T]) named(err error) error {
+	if err == nil || rcm.name == "" {
+		return err
+	}
+	return fmt.Errorf("cache %q: %w", rcm.name, err)
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheManagerWithName(t *testing.T) {
+	loadErr := errors.New("connection refused")
+	loader := &mockUserLoader{users: []models.User{{ID: 1, Name: "John"}}}
+	logs := &recordingLogger{}
+	cache := NewCacheManager[models.User](loader).WithTTL(time.Minute).WithName("users").WithLogger(logs)
+	events := cache.Subscribe()
+	assert.Equal(t, "users", cache.Name())
+
+	_, err := cache.Get(1)
+	assert.ErrorIs(t, err, ErrCacheNotInitialized)
+	assert.EqualError(t, err, `cache "users": cache not initialized: call Refresh first`)
+
+	assert.NoError(t, cache.Refresh())
+	<-events // init
+	<-events // refresh
+	_, err = cache.Get(2)
+	assert.EqualError(t, err, `cache "users": item with ID 2 not found`)
+
+	cache.lastFetch = time.Now().Add(-2 * time.Minute)
+	_, err = cache.Get(1)
+	assert.ErrorIs(t, err, ErrCacheExpired)
+	assert.Contains(t, err.Error(), `"users"`)
+
+	t.Run("refresh errors, events and stats", func(t *testing.T) {
+		loader.err = loadErr
+		err := cache.Refresh()
+		assert.ErrorIs(t, err, loadErr)
+		assert.EqualError(t, err, `cache "users": connection refused`)
+		assert.EqualError(t, cache.RecentErrors()[0].Err, err.Error())
+
+		event := <-events
+		assert.Equal(t, EventError, event.Kind)
+		assert.Equal(t, "users", event.Cache)
+
+		assert.Equal(t, "users", cache.Stats().Name)
+		encoded, _ := json.Marshal(cache.expvarStats())
+		assert.Contains(t, string(encoded), `"name":"users"`)
+	})
+
+	t.Run("log lines", func(t *testing.T) {
+		cache.logf("cache auto refresh failed: %v", loadErr)
+		assert.Equal(t, []string{`cache "users": cache auto refresh failed: connection refused`}, logs.messages)
+	})
+
+	t.Run("unnamed caches keep their messages", func(t *testing.T) {
+		_, err := NewCacheManager[models.User](loader).Get(1)
+		assert.EqualError(t, err, ErrCacheNotInitialized.Error())
+	})
+}
+
+func TestRelatedCacheManagerWithName(t *testing.T) {
+	loader := &mockOrderLoader{err: errors.New("timeout")}
+	cache := NewRelatedCacheManager[models.Order](loader, time.Minute).WithName("orders")
+	assert.EqualError(t, cache.Refresh(), `cache "orders": timeout`)
+}
@@ -0,0 +1,53 @@
+package cache
+
+import "slices"
+
+// sortItems stably sorts items by less when it is set
+func sortItems[T any](items []T, less func(a, b T) bool) []T {
+	if less == nil {
+		return items
+	}
+	slices.SortStableFunc(items, func(a, b T) int {
+		switch {
+		case less(a, b):
+			return -1
+		case less(b, a):
+			return 1
+		default:
+			return 0
+		}
+	})
+	return items
+}
+
+// sort orders items by the WithDeterministicOrder func, a panicking
+// comparison counting as equal under WithPanicRecovery. It must be called
+// without the lock.
+func (cm *CacheManager[T]) sort(items []T) []T {
+	if cm.less == nil || !cm.recoverPanics {
+		return sortItems(items, cm.less)
+	}
+	g, report := cm.guard()
+	defer report()
+	return sortItems(items, func(a, b T) (less bool) {
+		g.call("order func", func() { less = cm.less(a, b) })
+		return less
+	})
+}
+
+// WithDeterministicOrder makes GetAll and Query return their items sorted
+// by less instead of in map iteration order, so that e.g. JSON responses
+// built from them are reproducible. Sorting costs O(n log n) per call.
+func (cm *CacheManager[T]) WithDeterministicOrder(less func(a, b T) bool) *CacheManager[T] {
+	cm.less = less
+	return cm
+}
+
+// WithDeterministicOrder makes GetAll, Query and GetByForeignKey return
+// their items sorted by less instead of in map iteration order, so that
+// e.g. JSON responses built from them are reproducible. Sorting costs
+// O(n log n) per call.
+func (rcm *RelatedCacheManager[T]) WithDeterministicOrder(less func(a, b T) bool) *RelatedCacheManager[T] {
+	rcm.less = less
+	return rcm
+}
@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheManagerWithDeterministicOrder(t *testing.T) {
+	users := make([]models.User, 50)
+	for i := range users {
+		users[i] = models.User{ID: uint(50 - i), Name: "User"}
+	}
+	byID := func(a, b models.User) bool { return a.ID < b.ID }
+	cache := NewCacheManager[models.User](&mockUserLoader{users: users}).WithDeterministicOrder(byID)
+	assert.NoError(t, cache.Refresh())
+
+	first := cache.GetAll()
+	assert.Len(t, first, 50)
+	assert.Equal(t, uint(1), first[0].ID)
+	assert.Equal(t, uint(50), first[49].ID)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, first, cache.GetAll())
+	}
+
+	even := NumberFieldCondition[models.User, uint]{
+		FieldExtractor: func(u models.User) uint { return u.ID % 2 },
+		Value:          0,
+		Operation:      "eq",
+	}
+	result := cache.Query(even)
+	assert.Len(t, result, 25)
+	assert.IsIncreasing(t, ids(result))
+}
+
+func TestRelatedCacheManagerWithDeterministicOrder(t *testing.T) {
+	orders := make([]models.Order, 30)
+	for i := range orders {
+		orders[i] = models.Order{ID: uint(30 - i), UserID: uint(i%3 + 1), Amount: float64(i)}
+	}
+	byAmount := func(a, b models.Order) bool { return a.Amount > b.Amount }
+	cache := NewRelatedCacheManager[models.Order](&mockOrderLoader{orders: orders}, time.Minute).
+		WithDeterministicOrder(byAmount)
+	assert.NoError(t, cache.Refresh())
+
+	first := cache.GetAll()
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, first, cache.GetAll())
+	}
+	assert.Equal(t, float64(29), first[0].Amount)
+
+	group := cache.GetByForeignKey(1)
+	assert.Len(t, group, 10)
+	assert.Equal(t, float64(27), group[0].Amount)
+	assert.Equal(t, float64(0), group[9].Amount)
+
+	over20 := NumberFieldCondition[models.Order, float64]{
+		FieldExtractor: func(o models.Order) float64 { return o.Amount },
+		Value:          20,
+		Operation:      "gt",
+	}
+	result := cache.Query(over20)
+	assert.Len(t, result, 9)
+	assert.Equal(t, float64(21), result[8].Amount)
+}
+
+// ids returns the IDs of items in order
+func ids[T Identifiable](items []T) []uint {
+	result := make([]uint, len(items))
+	for i, item := range items {
+		result[i] = item.GetID()
+	}
+	return result
+}
@@ -0,0 +1,31 @@
+package cache
+
+// QueryMap returns mapper applied to each item matching the condition, e.g.
+// to answer with DTOs or just names, in a single pass under the read lock.
+// Like Query it returns nil when the cache may not serve its data, answers
+// indexed conditions from the index and follows WithDeterministicOrder.
+// mapper may run under the read lock, so it must not call back into the
+// cache.
+func QueryMap[T Identifiable, R any](cm *CacheManager[T], condition QueryCondition[T], mapper func(T) R) []R {
+	result := make([]R, 0)
+	var matched []T // kept for sorting under WithDeterministicOrder
+	sorted := cm.less != nil
+	ok := cm.scan(condition, func(item T) {
+		if sorted {
+			matched = append(matched, item)
+		} else {
+			result = append(result, mapper(item))
+		}
+	})
+	if !ok {
+		return cm.executeWithLock(true, func() interface{} {
+			return unavailableList[R](cm)
+		}).([]R)
+	}
+	g, report := cm.guard()
+	defer report()
+	for _, item := range cm.sort(matched) {
+		g.call("aggregate func", func() { result = append(result, mapper(item)) })
+	}
+	return result
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryMap(t *testing.T) {
+	cache := NewSyntheticCacheManager(20, syntheticUser)
+	inExample3 := StringFieldCondition[models.User]{
+		FieldExtractor: func(u models.User) string { return u.Email },
+		Value:          "@example3.com",
+		Operation:      "endsWith",
+	}
+	name := func(u models.User) string { return u.Name }
+
+	assert.ElementsMatch(t, []string{"user-3", "user-13"}, QueryMap(cache, inExample3, name))
+
+	t.Run("follows the deterministic order", func(t *testing.T) {
+		cache.WithDeterministicOrder(func(a, b models.User) bool { return a.ID > b.ID })
+		defer cache.WithDeterministicOrder(nil)
+		assert.Equal(t, []string{"user-13", "user-3"}, QueryMap(cache, inExample3, name))
+	})
+
+	t.Run("maps to aggregates", func(t *testing.T) {
+		lengths := QueryMap(cache, inExample3, func(u models.User) int { return len(u.Email) })
+		assert.ElementsMatch(t, []int{len("user-3@example3.com"), len("user-13@example3.com")}, lengths)
+	})
+
+	t.Run("respects expiry", func(t *testing.T) {
+		expiring := NewCacheManager[models.User](&mockUserLoader{users: []models.User{{ID: 1, Name: "John"}}}).WithTTL(time.Minute)
+		assert.Nil(t, QueryMap(expiring, NotCondition[models.User]{Inner: inExample3}, name), "never refreshed")
+		assert.NoError(t, expiring.Refresh())
+		assert.Equal(t, []string{"JOHN"}, QueryMap(expiring, NotCondition[models.User]{Inner: inExample3}, func(u models.User) string {
+			return strings.ToUpper(u.Name)
+		}))
+		expiring.lastFetch = time.Now().Add(-2 * time.Minute)
+		assert.Nil(t, QueryMap(expiring, NotCondition[models.User]{Inner: inExample3}, name))
+	})
+}
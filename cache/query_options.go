@@ -0,0 +1,41 @@
+package cache
+
+import "sort"
+
+// QueryOptions sorts and pages the results of QueryWithOptions
+type QueryOptions[T any] struct {
+	Less   func(a, b T) bool // result order; nil orders by ID
+	Offset int               // matches to skip
+	Limit  int               // maximum number of results, 0 for no limit
+}
+
+// QueryWithOptions returns a page of the items matching the condition,
+// e.g. for a paginated search endpoint. The matches are sorted by
+// opts.Less, with ties broken by ID so every call returns the same order
+// and pages do not overlap, and then cut to opts.Offset and opts.Limit.
+func (cm *CacheManager[T]) QueryWithOptions(condition QueryCondition[T], opts QueryOptions[T]) []T {
+	items := cm.query(condition)
+	if items == nil {
+		return nil
+	}
+
+	less := opts.Less
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if less != nil {
+			if less(a, b) {
+				return true
+			}
+			if less(b, a) {
+				return false
+			}
+		}
+		return a.GetID() < b.GetID()
+	})
+
+	items = items[min(max(opts.Offset, 0), len(items)):]
+	if opts.Limit > 0 && opts.Limit < len(items) {
+		items = items[:opts.Limit]
+	}
+	return items
+}
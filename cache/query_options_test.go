@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheManagerQueryWithOptions(t *testing.T) {
+	cache := NewSyntheticCacheManager(30, func(id uint) models.User {
+		return models.User{ID: id, Name: []string{"b", "a", "c"}[id%3]}
+	})
+	all := StringFieldCondition[models.User]{FieldExtractor: func(u models.User) string { return u.Name }, Operation: "startsWith"}
+	byName := func(a, b models.User) bool { return a.Name < b.Name }
+
+	t.Run("orders by ID without a comparator", func(t *testing.T) {
+		got := cache.QueryWithOptions(all, QueryOptions[models.User]{Limit: 3})
+		assert.Equal(t, []uint{1, 2, 3}, ids(got))
+	})
+
+	t.Run("pages are deterministic and disjoint", func(t *testing.T) {
+		opts := QueryOptions[models.User]{Less: byName, Limit: 4}
+		first := cache.QueryWithOptions(all, opts)
+		assert.Equal(t, []uint{1, 4, 7, 10}, ids(first), "equal names are ordered by ID")
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, first, cache.QueryWithOptions(all, opts))
+		}
+
+		opts.Offset = 8
+		second := cache.QueryWithOptions(all, opts)
+		assert.Equal(t, []uint{25, 28, 3, 6}, ids(second))
+	})
+
+	t.Run("offsets past the end", func(t *testing.T) {
+		assert.Empty(t, cache.QueryWithOptions(all, QueryOptions[models.User]{Offset: 100}))
+		assert.Len(t, cache.QueryWithOptions(all, QueryOptions[models.User]{Offset: -5}), 30)
+	})
+
+	t.Run("an uninitialized cache returns nil", func(t *testing.T) {
+		cold := NewCacheManager[models.User](&mockUserLoader{})
+		assert.Nil(t, cold.QueryWithOptions(all, QueryOptions[models.User]{}))
+	})
+}
@@ -0,0 +1,56 @@
+package cache
+
+// readOnlyView is a point-in-time copy of a cache that rejects mutations
+type readOnlyView[T Identifiable] struct {
+	data map[uint]T
+	less func(a, b T) bool
+}
+
+// ReadOnlyView returns a snapshot of the cached data for consumers that must
+// not refresh or modify the cache. Reads are served from a copy taken now,
+// so later refreshes do not show through; Refresh fails with ErrReadOnly
+// and Clear does nothing. Taking the view copies the data map, O(n). An
+// expired or never refreshed cache yields an empty view.
+func (cm *CacheManager[T]) ReadOnlyView() Cache[T] {
+	result := cm.executeWithLock(true, func() interface{} {
+		view := &readOnlyView[T]{data: make(map[uint]T), less: cm.less}
+		if cm.listAllowed() {
+			for id, item := range cm.data {
+				view.data[id] = item
+			}
+		}
+		return view
+	})
+	return result.(*readOnlyView[T])
+}
+
+func (v *readOnlyView[T]) Get(id uint) (T, bool) {
+	item, exists := v.data[id]
+	return item, exists
+}
+
+func (v *readOnlyView[T]) GetAll() []T {
+	items := make([]T, 0, len(v.data))
+	for _, item := range v.data {
+		items = append(items, item)
+	}
+	return sortItems(items, v.less)
+}
+
+func (v *readOnlyView[T]) Query(condition QueryCondition[T]) []T {
+	result := make([]T, 0)
+	for _, item := range v.data {
+		if condition.Match(item) {
+			result = append(result, item)
+		}
+	}
+	return sortItems(result, v.less)
+}
+
+// Refresh is rejected: a view cannot reload the cache
+func (v *readOnlyView[T]) Refresh() error {
+	return ErrReadOnly
+}
+
+// Clear does nothing: a view cannot modify the cache
+func (v *readOnlyView[T]) Clear() {}
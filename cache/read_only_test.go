@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheManagerReadOnlyView(t *testing.T) {
+	loader := &mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+		{ID: 2, Name: "Jane", Email: "jane@example.com"},
+	}}
+	cache := NewCacheManager[models.User](loader)
+	assert.Empty(t, cache.ReadOnlyView().GetAll(), "nothing to see before a refresh")
+	assert.NoError(t, cache.Refresh())
+
+	view := cache.ReadOnlyView()
+	user, ok := view.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, "John", user.Name)
+	assert.Len(t, view.GetAll(), 2)
+	assert.Len(t, view.Query(StringFieldCondition[models.User]{
+		FieldExtractor: func(u models.User) string { return u.Name },
+		Value:          "Ja",
+		Operation:      "startsWith",
+	}), 1)
+
+	t.Run("mutations are rejected", func(t *testing.T) {
+		assert.ErrorIs(t, view.Refresh(), ErrReadOnly)
+		view.Clear()
+		assert.Len(t, view.GetAll(), 2)
+		assert.Len(t, cache.GetAll(), 2, "the cache is untouched")
+	})
+
+	t.Run("the view is a point-in-time snapshot", func(t *testing.T) {
+		loader.users = []models.User{{ID: 3, Name: "Bob"}}
+		assert.NoError(t, cache.Refresh())
+		cache.Set(models.User{ID: 4, Name: "Alice"})
+
+		_, ok := view.Get(3)
+		assert.False(t, ok)
+		_, ok = view.Get(1)
+		assert.True(t, ok)
+		assert.Len(t, view.GetAll(), 2)
+	})
+}
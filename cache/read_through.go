@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// batchReply is the outcome of a read-through load for one waiter
+type batchReply[T any] struct {
+	item T
+	err  error
+}
+
+// batcher coalesces concurrent read-through misses into batched loads
+type batcher[T any] struct {
+	mu       sync.Mutex
+	window   time.Duration
+	maxBatch int
+	pending  map[uint][]chan batchReply[T] // waiters per missing ID
+	order    []uint                        // missing IDs in arrival order
+	timer    *time.Timer
+	gen      uint64 // bumped per batch so a stale timer does not flush the next one
+}
+
+// WithBatchLoad makes GetOrLoad coalesce misses the way a GraphQL dataloader
+// does: missing IDs requested within window of the first one, or until
+// maxBatch distinct IDs are collected, are fetched with a single LoadMany
+// call whose results are fanned out to every caller.
+func (cm *CacheManager[T]) WithBatchLoad(window time.Duration, maxBatch int) *CacheManager[T] {
+	if maxBatch < 1 {
+		maxBatch = 1
+	}
+	cm.batch = &batcher[T]{
+		window:   window,
+		maxBatch: maxBatch,
+		pending:  make(map[uint][]chan batchReply[T]),
+	}
+	return cm
+}
+
+// GetOrLoad returns the cached item or, on a miss, loads it through the
+// loader's LoadMany and caches it. A loaded entry is fresh as of its own
+// load time: it does not reset the cache-wide fetch time, and it is served
+// for a full TTL, by Get and GetWithMeta as well, even when the rest of the
+// cache has expired.
+func (cm *CacheManager[T]) GetOrLoad(id uint) (T, error) {
+	if item, ok := cm.getFresh(id); ok {
+		cm.stats.record(true)
+		return item, nil
+	}
+	cm.stats.record(false)
+
+	if cm.batch == nil {
+		items, err := cm.loadMany([]uint{id})
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		res := cm.replyFor(id, items)
+		return res.item, res.err
+	}
+
+	reply := make(chan batchReply[T], 1)
+	cm.batch.enqueue(id, reply, cm.flushBatch)
+	res := <-reply
+	return res.item, res.err
+}
+
+// getFresh returns a cached item if it may be served: the cache has not
+// expired, the expiry policy serves stale data, or the entry itself was
+// loaded within the TTL
+func (cm *CacheManager[T]) getFresh(id uint) (T, bool) {
+	defer cm.notifyExpire()
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	item, exists := cm.data[id]
+	if !exists {
+		return item, false
+	}
+	if !cm.isExpired() || cm.getPolicy == ExpiryServeStale || cm.entryFresh(id) {
+		cm.recordAccess(id)
+		return item, true
+	}
+	var zero T
+	return zero, false
+}
+
+// entryFresh reports whether an entry was loaded on its own by GetOrLoad
+// within the TTL, so it may be served after the rest of the cache expired.
+// Refreshed and pushed entries follow the cache-wide clock, and an expiry
+// predicate that fired expires every entry. Callers must hold the lock.
+func (cm *CacheManager[T]) entryFresh(id uint) bool {
+	m := cm.meta[id]
+	return m != nil && m.readThrough && cm.ttl > 0 && !cm.signaled.Load() && time.Since(m.loadedAt) <= cm.ttl
+}
+
+// loadMany loads ids through the BatchLoader and caches the results
+func (cm *CacheManager[T]) loadMany(ids []uint) ([]T, error) {
+	cm.mu.RLock()
+	loader, ok := cm.loader.(BatchLoader[T])
+	cm.mu.RUnlock()
+	if !ok {
+		return nil, cm.named(fmt.Errorf("loader does not support batch loading"))
+	}
+
+	var items []T
+	var err error
+	if perr := cm.recoverLoad(func() { items, err = loader.LoadMany(ids) }); perr != nil {
+		err = perr
+	}
+	if err != nil {
+		return nil, cm.named(err)
+	}
+
+	cm.executeWithLock(false, func() interface{} {
+		now := time.Now()
+		for _, item := range items {
+			cm.putItem(item, now)
+			if m := cm.meta[item.GetID()]; m != nil { // nil if evicted right away
+				m.readThrough = true
+			}
+		}
+		return nil
+	})
+	return items, nil
+}
+
+// replyFor picks the item with the given ID out of a load result
+func (cm *CacheManager[T]) replyFor(id uint, items []T) batchReply[T] {
+	for _, item := range items {
+		if item.GetID() == id {
+			return batchReply[T]{item: item}
+		}
+	}
+	var zero T
+	return batchReply[T]{item: zero, err: cm.named(fmt.Errorf("item with ID %d not found", id))}
+}
+
+// flushBatch loads a batch of IDs and answers their waiters
+func (cm *CacheManager[T]) flushBatch(ids []uint, waiters map[uint][]chan batchReply[T]) {
+	items, err := cm.loadMany(ids)
+	for _, id := range ids {
+		reply := batchReply[T]{err: err}
+		if err == nil {
+			reply = cm.replyFor(id, items)
+		}
+		for _, ch := range waiters[id] {
+			ch <- reply
+		}
+	}
+}
+
+// enqueue adds a waiter for id and flushes the batch once it is full or its
+// window elapses. A second request for a pending ID joins the first.
+func (b *batcher[T]) enqueue(id uint, reply chan batchReply[T], flush func([]uint, map[uint][]chan batchReply[T])) {
+	b.mu.Lock()
+	if _, exists := b.pending[id]; !exists {
+		b.order = append(b.order, id)
+	}
+	b.pending[id] = append(b.pending[id], reply)
+
+	if len(b.order) >= b.maxBatch {
+		ids, waiters := b.take()
+		b.mu.Unlock()
+		flush(ids, waiters)
+		return
+	}
+	if b.timer == nil {
+		gen := b.gen
+		b.timer = time.AfterFunc(b.window, func() {
+			b.mu.Lock()
+			if b.gen != gen {
+				b.mu.Unlock()
+				return
+			}
+			ids, waiters := b.take()
+			b.mu.Unlock()
+			flush(ids, waiters)
+		})
+	}
+	b.mu.Unlock()
+}
+
+// take removes the pending batch. Callers must hold b.mu.
+func (b *batcher[T]) take() ([]uint, map[uint][]chan batchReply[T]) {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.gen++
+	ids, waiters := b.order, b.pending
+	b.order = nil
+	b.pending = make(map[uint][]chan batchReply[T])
+	return ids, waiters
+}
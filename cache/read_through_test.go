@@ -0,0 +1,166 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockBatchUserLoader serves LoadMany from a fixed set of users and records
+// every batch it receives
+type mockBatchUserLoader struct {
+	mockUserLoader
+	mu      sync.Mutex
+	batches [][]uint
+}
+
+func (m *mockBatchUserLoader) LoadMany(ids []uint) ([]models.User, error) {
+	m.mu.Lock()
+	m.batches = append(m.batches, append([]uint(nil), ids...))
+	m.mu.Unlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	var result []models.User
+	for _, user := range m.users {
+		for _, id := range ids {
+			if user.ID == id {
+				result = append(result, user)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (m *mockBatchUserLoader) batchCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.batches)
+}
+
+func newBatchUserLoader(n int) *mockBatchUserLoader {
+	loader := &mockBatchUserLoader{}
+	for i := 1; i <= n; i++ {
+		loader.users = append(loader.users, models.User{ID: uint(i), Name: "user"})
+	}
+	return loader
+}
+
+func TestCacheManagerGetOrLoad(t *testing.T) {
+	loader := newBatchUserLoader(3)
+	cache := NewCacheManager[models.User](loader)
+
+	user, err := cache.GetOrLoad(2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), user.ID)
+
+	_, err = cache.GetOrLoad(2)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, loader.batchCount(), "the loaded entry is served from the cache")
+
+	_, err = cache.GetOrLoad(9)
+	assert.EqualError(t, err, "item with ID 9 not found")
+
+	loader.err = errors.New("database unavailable")
+	_, err = cache.GetOrLoad(3)
+	assert.EqualError(t, err, "database unavailable")
+
+	plain := NewCacheManager[models.User](&mockUserLoader{})
+	_, err = plain.GetOrLoad(1)
+	assert.Error(t, err, "GetOrLoad needs a BatchLoader")
+}
+
+func TestCacheManagerGetOrLoadBatching(t *testing.T) {
+	const n = 20
+
+	t.Run("concurrent distinct misses share one load", func(t *testing.T) {
+		loader := newBatchUserLoader(n)
+		cache := NewCacheManager[models.User](loader).WithBatchLoad(50*time.Millisecond, 100)
+
+		var wg sync.WaitGroup
+		var failures atomic.Int32
+		for i := 1; i <= n; i++ {
+			wg.Add(1)
+			go func(id uint) {
+				defer wg.Done()
+				user, err := cache.GetOrLoad(id)
+				if err != nil || user.ID != id {
+					failures.Add(1)
+				}
+			}(uint(i))
+		}
+		wg.Wait()
+
+		assert.Zero(t, failures.Load())
+		assert.Equal(t, 1, loader.batchCount())
+		assert.Len(t, loader.batches[0], n)
+	})
+
+	t.Run("a full batch is loaded without waiting", func(t *testing.T) {
+		loader := newBatchUserLoader(n)
+		cache := NewCacheManager[models.User](loader).WithBatchLoad(time.Hour, n/2)
+
+		var wg sync.WaitGroup
+		for i := 1; i <= n; i++ {
+			wg.Add(1)
+			go func(id uint) {
+				defer wg.Done()
+				_, err := cache.GetOrLoad(id)
+				assert.NoError(t, err)
+			}(uint(i))
+		}
+		wg.Wait()
+		assert.Equal(t, 2, loader.batchCount())
+	})
+}
+
+func TestCacheManagerGetOrLoadIntoExpiredCache(t *testing.T) {
+	loader := newBatchUserLoader(2)
+	cache := NewCacheManager[models.User](loader).WithTTL(time.Minute)
+	assert.NoError(t, cache.Refresh())
+	expiredAt := time.Now().Add(-time.Hour)
+	cache.lastFetch = expiredAt
+	for _, m := range cache.meta {
+		m.loadedAt = expiredAt
+	}
+
+	loader.users = append(loader.users, models.User{ID: 3, Name: "late"})
+	user, err := cache.GetOrLoad(3)
+	assert.NoError(t, err)
+	assert.Equal(t, "late", user.Name)
+	assert.Equal(t, expiredAt, cache.lastFetch, "a single-key load must not reset the cache-wide clock")
+
+	_, err = cache.GetOrLoad(3)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, loader.batchCount(), "the lazily loaded entry is fresh on its own")
+
+	_, err = cache.GetOrLoad(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, loader.batchCount(), "entries from the expired refresh are reloaded")
+
+	user, err = cache.Get(3)
+	assert.NoError(t, err, "Get serves the lazily loaded entry within its own TTL")
+	assert.Equal(t, "late", user.Name)
+	_, meta, ok := cache.GetWithMeta(3)
+	assert.True(t, ok)
+	assert.False(t, meta.Stale)
+
+	_, err = cache.Get(2)
+	assert.EqualError(t, err, "cache expired", "refreshed entries follow the cache-wide expiry")
+	_, _, ok = cache.GetWithMeta(2)
+	assert.False(t, ok)
+
+	cache.meta[3].loadedAt = time.Now().Add(-2 * time.Minute)
+	_, err = cache.Get(3)
+	assert.EqualError(t, err, "cache expired", "the entry expires after its own TTL")
+
+	cache.Set(models.User{ID: 3, Name: "pushed"})
+	_, err = cache.Get(3)
+	assert.EqualError(t, err, "cache expired", "a pushed entry is not a read-through load")
+}
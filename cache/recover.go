@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// WithPanicRecovery makes the cache recover panics in the user-supplied
+// callbacks it runs instead of crashing the process: loaders, including
+// LoadOne and LoadMany, query conditions, the WithExpiryFunc predicate, the
+// equality, index key, order and search field funcs, attached indexes,
+// Patch mutations, aggregate extractors and QueryMap mappers, and the
+// OnRefresh, OnExpire and change hooks. A recovered panic becomes an error
+// wrapping ErrCallbackPanic that is kept in RecentErrors and sent to
+// subscribers as an EventError. A panicking loader fails the load with
+// that error. Any other panicking callback has its result dropped: a
+// condition or the expiry predicate counts as false, a comparison as a
+// change, the item is left out of the index, order or result it was
+// computed for, and Patch leaves the item unchanged and reports false.
+// Read-only views are detached copies and do not recover panics.
+func (cm *CacheManager[T]) WithPanicRecovery(enabled bool) *CacheManager[T] {
+	cm.recoverPanics = enabled
+	return cm
+}
+
+// panicError converts a recovered panic value into an error
+func panicError(callback string, r any) error {
+	return fmt.Errorf("%w: %s: %v", ErrCallbackPanic, callback, r)
+}
+
+// callbackGuard recovers the panics of the callbacks one operation runs when
+// enabled, keeping the first for reporting once the lock is released
+type callbackGuard struct {
+	enabled bool
+	err     error
+}
+
+// call runs fn and reports whether it returned without panicking
+func (g *callbackGuard) call(callback string, fn func()) (ok bool) {
+	if !g.enabled {
+		fn()
+		return true
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			if g.err == nil {
+				g.err = panicError(callback, r)
+			}
+			ok = false
+		}
+	}()
+	fn()
+	return true
+}
+
+// guard returns a callbackGuard for one operation. report records its panic
+// and must be called without the lock once the operation is done.
+func (cm *CacheManager[T]) guard() (g *callbackGuard, report func()) {
+	g = &callbackGuard{enabled: cm.recoverPanics}
+	return g, func() {
+		if g.err != nil {
+			cm.reportPanic(g.err)
+		}
+	}
+}
+
+// recordPanic records a recovered panic. Callers must hold the write lock.
+func (cm *CacheManager[T]) recordPanic(err error) {
+	cm.errorLog.add(err, time.Now())
+	cm.events.emit(Event{Kind: EventError, Err: err})
+}
+
+// reportPanic records a recovered panic. It must be called without the lock.
+func (cm *CacheManager[T]) reportPanic(err error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.recordPanic(err)
+}
+
+// callLocked runs a callback under the write lock, recording a recovered
+// panic right away. It reports whether fn returned without panicking.
+func (cm *CacheManager[T]) callLocked(callback string, fn func()) bool {
+	g := callbackGuard{enabled: cm.recoverPanics}
+	if g.call(callback, fn) {
+		return true
+	}
+	cm.recordPanic(g.err)
+	return false
+}
+
+// safeCall runs a hook, recovering a panic when WithPanicRecovery is set. It
+// must be called without the lock.
+func (cm *CacheManager[T]) safeCall(callback string, hook func()) {
+	g, report := cm.guard()
+	defer report()
+	g.call(callback, hook)
+}
+
+// load runs a loader method, turning a panic into an error when
+// WithPanicRecovery is set. Callers read the loader under the lock.
+func (cm *CacheManager[T]) load(load func() ([]T, error)) (items []T, err error) {
+	g := callbackGuard{enabled: cm.recoverPanics}
+	if !g.call("loader", func() { items, err = load() }) {
+		return nil, g.err
+	}
+	return items, err
+}
+
+// recoverLoad runs a LoadOne, LoadMany or Verify load, turning a panic into
+// an error it also records, as no refresh does. It must be called without
+// the lock.
+func (cm *CacheManager[T]) recoverLoad(load func()) error {
+	g, report := cm.guard()
+	defer report()
+	g.call("loader", load)
+	return g.err
+}
+
+// guardedCondition is a query condition whose panics count as no match
+type guardedCondition[T any] struct {
+	inner QueryCondition[T]
+	guard *callbackGuard
+}
+
+func (g *guardedCondition[T]) Match(item T) (matched bool) {
+	g.guard.call("query condition", func() { matched = g.inner.Match(item) })
+	return matched
+}
+
+// recoverCondition wraps a query condition so that g recovers its panics
+func recoverCondition[T any](condition QueryCondition[T], g *callbackGuard) QueryCondition[T] {
+	if !g.enabled {
+		return condition
+	}
+	return &guardedCondition[T]{inner: condition, guard: g}
+}
+
+// guardCondition wraps a query condition for a single query when
+// WithPanicRecovery is set. report records a panic of the condition and must
+// be called without the lock once the query is done.
+func (cm *CacheManager[T]) guardCondition(condition QueryCondition[T]) (guarded QueryCondition[T], report func()) {
+	g, report := cm.guard()
+	return recoverCondition(condition, g), report
+}
+
+// guardedEquals returns the WithEquals func with its panics recovered by g.
+// A panicking comparison counts as a change.
+func (cm *CacheManager[T]) guardedEquals(g *callbackGuard) func(a, b T) bool {
+	if cm.equals == nil || !g.enabled {
+		return cm.equals
+	}
+	return func(a, b T) (equal bool) {
+		g.call("equality func", func() { equal = cm.equals(a, b) })
+		return equal
+	}
+}
+
+// expiryFired calls the WithExpiryFunc predicate. The lock may be held here,
+// so a recovered panic, which counts as not expired, is left for the next
+// notifyExpire to report.
+func (cm *CacheManager[T]) expiryFired() (fired bool) {
+	g := callbackGuard{enabled: cm.recoverPanics}
+	if !g.call("expiry func", func() { fired = cm.expiryFunc() }) {
+		cm.expiryPanic.CompareAndSwap(nil, &g.err)
+	}
+	return fired
+}
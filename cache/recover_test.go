@@ -0,0 +1,226 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type panickingUserLoader struct{}
+
+func (panickingUserLoader) Load() ([]models.User, error) {
+	panic("database driver bug")
+}
+
+func TestCacheManagerWithPanicRecovery(t *testing.T) {
+	cache := NewSyntheticCacheManager(10, syntheticUser).WithPanicRecovery(true)
+	events := cache.Subscribe()
+
+	t.Run("a panicking condition is reported", func(t *testing.T) {
+		got := cache.Query(StringFieldCondition[models.User]{
+			FieldExtractor: func(u models.User) string {
+				if u.ID == 3 {
+					panic("nil pointer in extractor")
+				}
+				return u.Name
+			},
+			Value:     "user-",
+			Operation: "startsWith",
+		})
+		assert.Len(t, got, 9, "the panicking item counts as not matching")
+
+		errs := cache.RecentErrors()
+		if assert.Len(t, errs, 1) {
+			assert.ErrorIs(t, errs[0].Err, ErrCallbackPanic)
+			assert.Contains(t, errs[0].Err.Error(), "nil pointer in extractor")
+		}
+		event := <-events
+		assert.Equal(t, EventError, event.Kind)
+
+		// The lock was released: writes still go through
+		cache.Set(models.User{ID: 11})
+		assert.True(t, cache.Has(11))
+	})
+
+	t.Run("panicking hooks are reported", func(t *testing.T) {
+		cache.OnRefresh(func(added, removed, changed int) { panic("hook bug") })
+		assert.NoError(t, cache.Refresh())
+		assert.Len(t, cache.RecentErrors(), 2)
+	})
+
+	t.Run("a panicking loader fails the refresh", func(t *testing.T) {
+		cache.SetLoader(panickingUserLoader{})
+		assert.ErrorIs(t, cache.Refresh(), ErrCallbackPanic)
+		assert.ErrorIs(t, cache.RefreshContext(context.Background()), ErrCallbackPanic)
+		assert.Len(t, cache.GetAll(), 10, "the cache keeps its data")
+	})
+}
+
+func TestCacheManagerPanicsByDefault(t *testing.T) {
+	cache := NewSyntheticCacheManager(1, syntheticUser).WithTTL(time.Minute)
+	assert.Panics(t, func() {
+		cache.Query(StringFieldCondition[models.User]{
+			FieldExtractor: func(models.User) string { panic("bug") },
+			Operation:      "eq",
+		})
+	})
+}
+
+// panickingUserLoader also panics on single and batch loads
+func (panickingUserLoader) LoadOne(uint) (models.User, bool, error) {
+	panic("driver bug in LoadOne")
+}
+
+func (panickingUserLoader) LoadMany([]uint) ([]models.User, error) {
+	panic("driver bug in LoadMany")
+}
+
+// panickingIndex is an attached Index whose Add panics for one ID
+type panickingIndex struct {
+	KeyIndex[models.User]
+	bad uint
+}
+
+func (p *panickingIndex) Add(id uint, item models.User) {
+	if id == p.bad {
+		panic("index bug")
+	}
+	p.KeyIndex.Add(id, item)
+}
+
+// assertPanicRecorded checks that the last recorded error is a recovered
+// panic of callback
+func assertPanicRecorded(t *testing.T, cache *CacheManager[models.User], callback string) {
+	t.Helper()
+	errs := cache.RecentErrors()
+	if assert.NotEmpty(t, errs) {
+		last := errs[len(errs)-1].Err
+		assert.ErrorIs(t, last, ErrCallbackPanic)
+		assert.Contains(t, last.Error(), callback)
+	}
+}
+
+func TestCacheManagerPanicRecoveryCallbacks(t *testing.T) {
+	newCache := func() *CacheManager[models.User] {
+		return NewSyntheticCacheManager(3, syntheticUser).WithPanicRecovery(true)
+	}
+
+	t.Run("equality func", func(t *testing.T) {
+		cache := newCache().
+			WithEquals(func(a, b models.User) bool { panic("equals bug") }).
+			OnRefresh(func(added, removed, changed int) {})
+
+		result := cache.Apply([]models.User{syntheticUser(1)})
+		assert.Equal(t, 1, result.Updated, "a panicking comparison counts as a change")
+		assert.Equal(t, 2, result.Removed)
+		assertPanicRecorded(t, cache, "equality func")
+
+		verify, err := cache.Verify(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{1}, verify.Different)
+	})
+
+	t.Run("index key func", func(t *testing.T) {
+		cache := newCache().WithIndexes(map[string]func(models.User) string{
+			"name": func(u models.User) string {
+				if u.ID == 2 {
+					panic("key bug")
+				}
+				return u.Name
+			},
+		})
+		assert.NoError(t, cache.Refresh())
+		assert.Empty(t, cache.QueryByIndex("name", "user-2"), "the item is left out of the index")
+		assert.Len(t, cache.QueryByIndex("name", "user-1"), 1)
+		assert.True(t, cache.Has(2), "the item is still cached")
+		assertPanicRecorded(t, cache, "index key func")
+	})
+
+	t.Run("attached index", func(t *testing.T) {
+		cache := newCache()
+		cache.WithIndex("name", &panickingIndex{
+			KeyIndex: *NewKeyIndex(func(u models.User) string { return u.Name }),
+			bad:      2,
+		})
+		assert.Len(t, cache.LookupIndex("name", "user-1"), 1)
+		assert.Empty(t, cache.LookupIndex("name", "user-2"))
+		assertPanicRecorded(t, cache, "index")
+	})
+
+	t.Run("search field func", func(t *testing.T) {
+		cache := newCache()
+		got := cache.QuerySearch(func(u models.User) string {
+			if u.ID == 3 {
+				panic("field bug")
+			}
+			return u.Name
+		}, "user-")
+		assert.Equal(t, []uint{1, 2}, ids(got))
+		assertPanicRecorded(t, cache, "search field func")
+	})
+
+	t.Run("Patch mutate", func(t *testing.T) {
+		cache := newCache()
+		_, ok := cache.Patch(1, func(u *models.User) {
+			u.Name = "half-patched"
+			panic("mutate bug")
+		})
+		assert.False(t, ok)
+		user, err := cache.Get(1)
+		assert.NoError(t, err)
+		assert.Equal(t, "user-1", user.Name, "the item is unchanged")
+		assertPanicRecorded(t, cache, "Patch mutate")
+	})
+
+	t.Run("aggregate extractor", func(t *testing.T) {
+		cache := newCache()
+		sum := SumBy(cache, And[models.User](), func(u models.User) int {
+			if u.ID == 2 {
+				panic("extractor bug")
+			}
+			return int(u.ID)
+		})
+		assert.Equal(t, 4, sum, "the panicking item is skipped")
+		assertPanicRecorded(t, cache, "aggregate func")
+	})
+
+	t.Run("QueryMap mapper", func(t *testing.T) {
+		cache := newCache().WithDeterministicOrder(func(a, b models.User) bool { return a.ID < b.ID })
+		names := QueryMap(cache, And[models.User](), func(u models.User) string {
+			if u.ID == 1 {
+				panic("mapper bug")
+			}
+			return u.Name
+		})
+		assert.Equal(t, []string{"user-2", "user-3"}, names)
+		assertPanicRecorded(t, cache, "aggregate func")
+	})
+
+	t.Run("order func", func(t *testing.T) {
+		cache := newCache().WithDeterministicOrder(func(a, b models.User) bool { panic("less bug") })
+		assert.Len(t, cache.GetAll(), 3)
+		assertPanicRecorded(t, cache, "order func")
+	})
+
+	t.Run("expiry func", func(t *testing.T) {
+		cache := newCache().WithExpiryFunc(func() bool { panic("expiry bug") })
+		user, err := cache.Get(1)
+		assert.NoError(t, err, "a panicking predicate counts as not expired")
+		assert.Equal(t, uint(1), user.ID)
+		assertPanicRecorded(t, cache, "expiry func")
+	})
+
+	t.Run("LoadOne and LoadMany", func(t *testing.T) {
+		cache := NewCacheManager[models.User](panickingUserLoader{}).
+			WithPanicRecovery(true).
+			WithLazyLoad(true)
+		_, err := cache.Get(1)
+		assert.ErrorIs(t, err, ErrCallbackPanic)
+		_, err = cache.GetOrLoad(2)
+		assert.ErrorIs(t, err, ErrCallbackPanic)
+		assertPanicRecorded(t, cache, "loader")
+	})
+}
@@ -1,31 +1,82 @@
 package cache
 
 import (
+	"slices"
 	"sync"
 	"time"
 )
 
-// RelatedCacheManager implements the RelatedCache interface
-type RelatedCacheManager[T ForeignKeyable] struct {
+// RelatedCacheManager implements the RelatedCache interface. Items are
+// indexed by the foreign key returned by fkFunc, which is GetUserID for
+// managers created with NewRelatedCacheManager, and by any named indexes
+// added with AddIndex.
+type RelatedCacheManager[T Identifiable] struct {
 	data      map[uint]T      // Primary key -> Entity
 	fkIndex   map[uint][]uint // Foreign key -> Primary keys
+	fkFunc    func(T) uint
 	mu        sync.RWMutex
 	loader    DataLoader[T]
 	ttl       time.Duration
 	lastFetch time.Time
+	enrich    func(items []T) error
+
+	less       func(a, b T) bool // result order, see WithDeterministicOrder
+	skipZeroFK bool
+	orphans    []uint // Primary keys of items without a foreign key
+
+	groupLoader func(fkID uint) ([]T, error)
+	groupFetch  map[uint]time.Time // Foreign key -> last group refresh
+
+	indexFuncs map[string]func(T) uint
+	indexes    map[string]map[uint][]uint // Index name -> key -> Primary keys
+
+	recoverPanics bool // see WithPanicRecovery
+	errorLog      errorRing
+	name          string // see WithName
 }
 
-// NewRelatedCacheManager creates a new related cache manager instance
+// NewRelatedCacheManager creates a new related cache manager instance. A
+// ttl of 0 keeps the data until the next Refresh. The ttl argument is kept
+// for compatibility; new code should pass 0 and configure it with WithTTL,
+// like CacheManager.
 func NewRelatedCacheManager[T ForeignKeyable](loader DataLoader[T], ttl time.Duration) *RelatedCacheManager[T] {
+	return NewIndexedCacheManager(loader, ttl, func(item T) uint { return item.GetUserID() })
+}
+
+// NewIndexedCacheManager creates a related cache manager whose foreign key
+// index uses fkExtractor, e.g. to index orders by product ID or to index
+// models that are not keyed to a user
+func NewIndexedCacheManager[T Identifiable](loader DataLoader[T], ttl time.Duration, fkExtractor func(T) uint) *RelatedCacheManager[T] {
 	return &RelatedCacheManager[T]{
 		data:      make(map[uint]T),
 		fkIndex:   make(map[uint][]uint),
+		fkFunc:    fkExtractor,
 		loader:    loader,
 		ttl:       ttl,
 		lastFetch: time.Time{},
+		errorLog:  newErrorRing(defaultErrorLogSize),
 	}
 }
 
+// WithTTL sets the TTL for the related cache manager. A ttl of 0 or less
+// never expires.
+func (rcm *RelatedCacheManager[T]) WithTTL(ttl time.Duration) *RelatedCacheManager[T] {
+	rcm.mu.Lock()
+	defer rcm.mu.Unlock()
+
+	rcm.ttl = ttl
+	return rcm
+}
+
+// WithEnrich sets a hook that runs on every refresh after the items are loaded
+// and before they are indexed. The hook may modify the items in place, e.g. to
+// attach related data fetched in bulk. If it returns an error the refresh
+// fails and the cache keeps its current contents.
+func (rcm *RelatedCacheManager[T]) WithEnrich(enrich func(items []T) error) *RelatedCacheManager[T] {
+	rcm.enrich = enrich
+	return rcm
+}
+
 // Get retrieves an item by ID
 func (rcm *RelatedCacheManager[T]) Get(id uint) (T, bool) {
 	rcm.mu.RLock()
@@ -40,15 +91,62 @@ func (rcm *RelatedCacheManager[T]) Get(id uint) (T, bool) {
 	return item, exists
 }
 
-// GetByForeignKey retrieves items by foreign key
+// WithSkipZeroForeignKey keeps items whose foreign key is zero, i.e. unset,
+// out of the foreign key index, so GetByForeignKey(0) no longer mixes them
+// with a legitimate key 0. They stay cached and are returned by Orphans.
+func (rcm *RelatedCacheManager[T]) WithSkipZeroForeignKey(skip bool) *RelatedCacheManager[T] {
+	rcm.mu.Lock()
+	defer rcm.mu.Unlock()
+
+	rcm.skipZeroFK = skip
+	if skip && len(rcm.fkIndex[0]) > 0 {
+		rcm.orphans = append(rcm.orphans, rcm.fkIndex[0]...)
+		delete(rcm.fkIndex, 0)
+	} else if !skip && len(rcm.orphans) > 0 {
+		rcm.fkIndex[0] = append(rcm.fkIndex[0], rcm.orphans...)
+		rcm.orphans = nil
+	}
+	return rcm
+}
+
+// Orphans returns the items without a foreign key when
+// WithSkipZeroForeignKey is set
+func (rcm *RelatedCacheManager[T]) Orphans() []T {
+	rcm.mu.RLock()
+	defer rcm.mu.RUnlock()
+
+	if rcm.isExpired() {
+		return nil
+	}
+
+	result := make([]T, 0, len(rcm.orphans))
+	for _, pk := range rcm.orphans {
+		if item, exists := rcm.data[pk]; exists {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// GetByForeignKey retrieves items by foreign key. With WithGroupLoader set
+// it reloads the group first if it is older than the TTL, see
+// RefreshForeignKey.
 func (rcm *RelatedCacheManager[T]) GetByForeignKey(fkID uint) []T {
 	rcm.mu.RLock()
+	if rcm.groupLoader != nil {
+		rcm.mu.RUnlock()
+		return rcm.getGroupReadThrough(fkID)
+	}
 	defer rcm.mu.RUnlock()
 
 	if rcm.isExpired() {
 		return nil
 	}
+	return rcm.groupItems(fkID)
+}
 
+// groupItems returns the items of a foreign key group. Callers hold the lock.
+func (rcm *RelatedCacheManager[T]) groupItems(fkID uint) []T {
 	pks := rcm.fkIndex[fkID]
 	result := make([]T, 0, len(pks))
 	for _, pk := range pks {
@@ -56,9 +154,142 @@ func (rcm *RelatedCacheManager[T]) GetByForeignKey(fkID uint) []T {
 			result = append(result, item)
 		}
 	}
+	return sortItems(result, rcm.less)
+}
+
+// ForEachByForeignKey calls fn for each item of a foreign key until fn
+// returns false. Unlike GetByForeignKey it allocates no result slice, which
+// suits hot read paths. fn runs under the read lock: it must not modify the
+// item or call back into the cache.
+func (rcm *RelatedCacheManager[T]) ForEachByForeignKey(fkID uint, fn func(T) bool) {
+	rcm.mu.RLock()
+	defer rcm.mu.RUnlock()
+
+	if rcm.isExpired() {
+		return
+	}
+
+	for _, pk := range rcm.fkIndex[fkID] {
+		if item, exists := rcm.data[pk]; exists && !fn(item) {
+			return
+		}
+	}
+}
+
+// GetByForeignKeys retrieves the items of several foreign keys under a
+// single read lock, e.g. the orders of every user on a page. Every requested
+// key is present in the result; keys without items map to an empty slice.
+func (rcm *RelatedCacheManager[T]) GetByForeignKeys(fkIDs ...uint) map[uint][]T {
+	rcm.mu.RLock()
+	defer rcm.mu.RUnlock()
+
+	if rcm.isExpired() {
+		return nil
+	}
+
+	result := make(map[uint][]T, len(fkIDs))
+	for _, fkID := range fkIDs {
+		result[fkID] = rcm.groupItems(fkID)
+	}
 	return result
 }
 
+// GetByForeignKeysFlat retrieves the items of all the given foreign keys as a
+// single slice, deduplicated by primary key. Items are grouped in the order
+// of fkIDs.
+func (rcm *RelatedCacheManager[T]) GetByForeignKeysFlat(fkIDs []uint) []T {
+	rcm.mu.RLock()
+	defer rcm.mu.RUnlock()
+
+	if rcm.isExpired() {
+		return nil
+	}
+
+	seen := make(map[uint]struct{})
+	result := make([]T, 0)
+	for _, fkID := range fkIDs {
+		for _, pk := range rcm.fkIndex[fkID] {
+			if _, dup := seen[pk]; dup {
+				continue
+			}
+			if item, exists := rcm.data[pk]; exists {
+				seen[pk] = struct{}{}
+				result = append(result, item)
+			}
+		}
+	}
+	return result
+}
+
+// GetByForeignKeyWhere retrieves the items of a foreign key that match the
+// condition in a single pass over the group
+func (rcm *RelatedCacheManager[T]) GetByForeignKeyWhere(fkID uint, condition QueryCondition[T]) []T {
+	g, report := rcm.guard()
+	defer report()
+	rcm.mu.RLock()
+	defer rcm.mu.RUnlock()
+
+	if rcm.isExpired() {
+		return nil
+	}
+
+	condition = recoverCondition(condition, g)
+	result := make([]T, 0)
+	for _, pk := range rcm.fkIndex[fkID] {
+		if item, exists := rcm.data[pk]; exists && condition.Match(item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// ForeignKeysMatching returns the distinct foreign keys, in ascending
+// order, that have at least one item matching the condition, e.g. the users
+// with a high-value order, for a follow-up lookup in the parent cache
+func (rcm *RelatedCacheManager[T]) ForeignKeysMatching(condition QueryCondition[T]) []uint {
+	g, report := rcm.guard()
+	defer report()
+	rcm.mu.RLock()
+	defer rcm.mu.RUnlock()
+
+	if rcm.isExpired() {
+		return nil
+	}
+
+	condition = recoverCondition(condition, g)
+	fks := make([]uint, 0)
+	for fk, pks := range rcm.fkIndex {
+		for _, pk := range pks {
+			if item, exists := rcm.data[pk]; exists && condition.Match(item) {
+				fks = append(fks, fk)
+				break
+			}
+		}
+	}
+	sortIDs(fks)
+	return fks
+}
+
+// CountByForeignKey returns the number of items per foreign key, e.g. how
+// many orders each user has. It reads the sizes of the foreign key index
+// without materializing any items.
+func (rcm *RelatedCacheManager[T]) CountByForeignKey() map[uint]int {
+	rcm.mu.RLock()
+	defer rcm.mu.RUnlock()
+
+	if rcm.isExpired() {
+		return nil
+	}
+
+	counts := make(map[uint]int, len(rcm.fkIndex))
+	for fk, pks := range rcm.fkIndex {
+		if len(pks) > 0 {
+			counts[fk] = len(pks)
+		}
+	}
+	return counts
+}
+
 // GetAll returns all items in the cache
 func (rcm *RelatedCacheManager[T]) GetAll() []T {
 	rcm.mu.RLock()
@@ -72,7 +303,24 @@ func (rcm *RelatedCacheManager[T]) GetAll() []T {
 	for _, item := range rcm.data {
 		items = append(items, item)
 	}
-	return items
+	return sortItems(items, rcm.less)
+}
+
+// Keys returns the primary keys of all items in the cache. The order is
+// nondeterministic; callers that need a stable order must sort the result.
+func (rcm *RelatedCacheManager[T]) Keys() []uint {
+	rcm.mu.RLock()
+	defer rcm.mu.RUnlock()
+
+	if rcm.isExpired() {
+		return nil
+	}
+
+	keys := make([]uint, 0, len(rcm.data))
+	for pk := range rcm.data {
+		keys = append(keys, pk)
+	}
+	return keys
 }
 
 // Refresh reloads the cache data
@@ -80,25 +328,51 @@ func (rcm *RelatedCacheManager[T]) Refresh() error {
 	rcm.mu.Lock()
 	defer rcm.mu.Unlock()
 
-	items, err := rcm.loader.Load()
+	if rcm.loader == nil {
+		return rcm.named(ErrNoLoader)
+	}
+	items, err := rcm.fetch(rcm.loader.Load, rcm.enrich)
 	if err != nil {
-		return err
+		return rcm.named(err)
 	}
 
-	newData := make(map[uint]T)
-	newFKIndex := make(map[uint][]uint)
+	rcm.install(rcm.index(items, rcm.skipZeroFK))
+	return nil
+}
+
+// relatedData is a dataset indexed by foreign key, ready to be installed
+type relatedData[T any] struct {
+	data    map[uint]T
+	fkIndex map[uint][]uint
+	orphans []uint
+}
 
+// index builds the maps for items. It touches no cache state, so it can
+// run without the lock.
+func (rcm *RelatedCacheManager[T]) index(items []T, skipZeroFK bool) relatedData[T] {
+	d := relatedData[T]{data: make(map[uint]T), fkIndex: make(map[uint][]uint)}
 	for _, item := range items {
 		pk := item.GetID()
-		fk := item.GetUserID()
-		newData[pk] = item
-		newFKIndex[fk] = append(newFKIndex[fk], pk)
+		fk := rcm.fkFunc(item)
+		d.data[pk] = item
+		if fk == 0 && skipZeroFK {
+			d.orphans = append(d.orphans, pk)
+			continue
+		}
+		d.fkIndex[fk] = append(d.fkIndex[fk], pk)
 	}
+	return d
+}
 
-	rcm.data = newData
-	rcm.fkIndex = newFKIndex
+// install replaces the dataset and marks the cache fetched. Callers hold
+// the write lock.
+func (rcm *RelatedCacheManager[T]) install(d relatedData[T]) {
+	rcm.data = d.data
+	rcm.fkIndex = d.fkIndex
+	rcm.orphans = d.orphans
+	rcm.groupFetch = nil
+	rcm.rebuildIndexes()
 	rcm.lastFetch = time.Now()
-	return nil
 }
 
 // Clear removes all items from the cache
@@ -107,14 +381,94 @@ func (rcm *RelatedCacheManager[T]) Clear() {
 	defer rcm.mu.Unlock()
 	rcm.data = make(map[uint]T)
 	rcm.fkIndex = make(map[uint][]uint)
+	rcm.orphans = nil
+	rcm.groupFetch = nil
+	rcm.rebuildIndexes()
+}
+
+// DeleteMany removes the given items under a single write lock and returns
+// how many were cached. Their foreign key index entries are removed too,
+// dropping groups that become empty.
+func (rcm *RelatedCacheManager[T]) DeleteMany(ids []uint) int {
+	rcm.mu.Lock()
+	defer rcm.mu.Unlock()
+
+	removed := 0
+	for _, pk := range ids {
+		item, exists := rcm.data[pk]
+		if !exists {
+			continue
+		}
+		delete(rcm.data, pk)
+		removed++
+		rcm.unindex(pk, item)
+	}
+	return removed
+}
+
+// unindex removes a primary key from the named indexes and from the foreign
+// key group of item, dropping the group if it becomes empty, or from the
+// orphans. Callers hold the write lock.
+func (rcm *RelatedCacheManager[T]) unindex(pk uint, item T) {
+	rcm.unindexNamed(pk, item)
+	fk := rcm.fkFunc(item)
+	if pks, indexed := rcm.fkIndex[fk]; indexed && slices.Contains(pks, pk) {
+		if pks = slices.DeleteFunc(pks, func(id uint) bool { return id == pk }); len(pks) > 0 {
+			rcm.fkIndex[fk] = pks
+		} else {
+			delete(rcm.fkIndex, fk)
+		}
+	} else {
+		rcm.orphans = slices.DeleteFunc(rcm.orphans, func(id uint) bool { return id == pk })
+	}
+}
+
+// Compact rebuilds the data map and foreign key index at their current
+// size, releasing the backing storage maps keep after many deletions. It is
+// O(n) and holds the write lock throughout, so call it during low-traffic
+// windows.
+func (rcm *RelatedCacheManager[T]) Compact() {
+	rcm.mu.Lock()
+	defer rcm.mu.Unlock()
+
+	data := make(map[uint]T, len(rcm.data))
+	for pk, item := range rcm.data {
+		data[pk] = item
+	}
+	fkIndex := make(map[uint][]uint, len(rcm.fkIndex))
+	for fk, pks := range rcm.fkIndex {
+		live := make([]uint, 0, len(pks))
+		for _, pk := range pks {
+			if _, exists := data[pk]; exists {
+				live = append(live, pk)
+			}
+		}
+		if len(live) > 0 {
+			fkIndex[fk] = live
+		}
+	}
+	orphans := make([]uint, 0, len(rcm.orphans))
+	for _, pk := range rcm.orphans {
+		if _, exists := data[pk]; exists {
+			orphans = append(orphans, pk)
+		}
+	}
+	rcm.data = data
+	rcm.fkIndex = fkIndex
+	rcm.orphans = orphans
+	rcm.rebuildIndexes()
 }
 
+// isExpired reports whether the data outlived the TTL. Like CacheManager, a
+// TTL of 0 or less never expires.
 func (rcm *RelatedCacheManager[T]) isExpired() bool {
-	return !rcm.lastFetch.IsZero() && time.Since(rcm.lastFetch) > rcm.ttl
+	return rcm.ttl > 0 && !rcm.lastFetch.IsZero() && time.Since(rcm.lastFetch) > rcm.ttl
 }
 
 // Query returns items that match the given condition
 func (rcm *RelatedCacheManager[T]) Query(condition QueryCondition[T]) []T {
+	g, report := rcm.guard()
+	defer report()
 	rcm.mu.RLock()
 	defer rcm.mu.RUnlock()
 
@@ -122,11 +476,12 @@ func (rcm *RelatedCacheManager[T]) Query(condition QueryCondition[T]) []T {
 		return nil
 	}
 
+	condition = recoverCondition(condition, g)
 	result := make([]T, 0)
 	for _, item := range rcm.data {
 		if condition.Match(item) {
 			result = append(result, item)
 		}
 	}
-	return result
+	return sortItems(result, rcm.less)
 }
@@ -1,6 +1,8 @@
 package cache
 
 import (
+	"errors"
+	"reflect"
 	"testing"
 	"time"
 
@@ -49,9 +51,397 @@ func TestRelatedCacheManager(t *testing.T) {
 		assert.Equal(t, float64(100), order.Amount)
 	})
 
+	t.Run("Keys returns all cached primary keys", func(t *testing.T) {
+		assert.ElementsMatch(t, []uint{1, 2, 3}, cache.Keys())
+	})
+
 	t.Run("Clear removes all items", func(t *testing.T) {
 		cache.Clear()
 		items := cache.GetAll()
 		assert.Len(t, items, 0)
+		assert.Empty(t, cache.Keys())
+	})
+}
+
+func TestRelatedCacheManagerCompact(t *testing.T) {
+	orders := make([]models.Order, 1000)
+	for i := range orders {
+		orders[i] = models.Order{ID: uint(i + 1), UserID: uint(i%10 + 1), Amount: 100}
+	}
+	cache := NewRelatedCacheManager[models.Order](&mockOrderLoader{orders: orders}, time.Minute)
+	assert.NoError(t, cache.Refresh())
+
+	// Keep only the orders of user 1
+	for pk, order := range cache.data {
+		if order.UserID != 1 {
+			delete(cache.data, pk)
+		}
+	}
+	dataBefore := reflect.ValueOf(cache.data).UnsafePointer()
+	indexBefore := reflect.ValueOf(cache.fkIndex).UnsafePointer()
+
+	cache.Compact()
+
+	assert.NotEqual(t, dataBefore, reflect.ValueOf(cache.data).UnsafePointer(), "Compact should allocate a new data map")
+	assert.NotEqual(t, indexBefore, reflect.ValueOf(cache.fkIndex).UnsafePointer(), "Compact should allocate a new index")
+	assert.Len(t, cache.fkIndex, 1, "empty foreign key buckets should be dropped")
+	assert.Len(t, cache.GetByForeignKey(1), 100)
+	assert.Empty(t, cache.GetByForeignKey(2))
+}
+
+func TestRelatedCacheManagerGetByForeignKeyWhere(t *testing.T) {
+	loader := &mockOrderLoader{orders: []models.Order{
+		{ID: 1, UserID: 1, Amount: 50},
+		{ID: 2, UserID: 1, Amount: 150},
+		{ID: 3, UserID: 1, Amount: 250},
+		{ID: 4, UserID: 2, Amount: 300},
+	}}
+	cache := NewRelatedCacheManager[models.Order](loader, 5*time.Minute)
+	assert.NoError(t, cache.Refresh())
+
+	overHundred := NumberFieldCondition[models.Order, float64]{
+		FieldExtractor: func(o models.Order) float64 { return o.Amount },
+		Value:          100,
+		Operation:      "gt",
+	}
+
+	orders := cache.GetByForeignKeyWhere(1, overHundred)
+	assert.Len(t, orders, 2)
+	for _, order := range orders {
+		assert.Equal(t, uint(1), order.UserID)
+		assert.Greater(t, order.Amount, float64(100))
+	}
+
+	assert.Len(t, cache.GetByForeignKeyWhere(2, overHundred), 1)
+	assert.Empty(t, cache.GetByForeignKeyWhere(3, overHundred))
+}
+
+// orderView is an order with the owning user's name attached for rendering
+type orderView struct {
+	models.Order
+	UserName string
+}
+
+type mockOrderViewLoader struct {
+	orders []models.Order
+}
+
+func (m *mockOrderViewLoader) Load() ([]orderView, error) {
+	views := make([]orderView, len(m.orders))
+	for i, order := range m.orders {
+		views[i] = orderView{Order: order}
+	}
+	return views, nil
+}
+
+func TestRelatedCacheManagerWithEnrich(t *testing.T) {
+	users := NewCacheManager[models.User](&mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John"},
+		{ID: 2, Name: "Jane"},
+	}})
+	assert.NoError(t, users.Refresh())
+
+	loader := &mockOrderViewLoader{orders: []models.Order{
+		{ID: 1, UserID: 1, Amount: 100},
+		{ID: 2, UserID: 2, Amount: 200},
+		{ID: 3, UserID: 2, Amount: 300},
+	}}
+	cache := NewRelatedCacheManager[orderView](loader, 5*time.Minute).
+		WithEnrich(func(items []orderView) error {
+			for i := range items {
+				user, err := users.Get(items[i].UserID)
+				if err != nil {
+					return err
+				}
+				items[i].UserName = user.Name
+			}
+			return nil
+		})
+
+	t.Run("enriched fields are present after refresh", func(t *testing.T) {
+		assert.NoError(t, cache.Refresh())
+
+		order, ok := cache.Get(1)
+		assert.True(t, ok)
+		assert.Equal(t, "John", order.UserName)
+		for _, order := range cache.GetByForeignKey(2) {
+			assert.Equal(t, "Jane", order.UserName)
+		}
+	})
+
+	t.Run("enrich failure keeps the cache", func(t *testing.T) {
+		loader.orders = append(loader.orders, models.Order{ID: 4, UserID: 3})
+		assert.Error(t, cache.Refresh())
+
+		_, ok := cache.Get(4)
+		assert.False(t, ok)
+		assert.Len(t, cache.GetAll(), 3)
+	})
+}
+
+func TestRelatedCacheManagerGetByForeignKeysFlat(t *testing.T) {
+	loader := &mockOrderLoader{orders: []models.Order{
+		{ID: 1, UserID: 1, Amount: 100},
+		{ID: 2, UserID: 1, Amount: 200},
+		{ID: 3, UserID: 2, Amount: 300},
+		{ID: 4, UserID: 3, Amount: 400},
+	}}
+	cache := NewRelatedCacheManager[models.Order](loader, 5*time.Minute)
+	assert.NoError(t, cache.Refresh())
+
+	orders := cache.GetByForeignKeysFlat([]uint{1, 2, 1, 9})
+	assert.Len(t, orders, 3, "overlapping keys must not duplicate items")
+
+	var total float64
+	ids := make(map[uint]bool)
+	for _, order := range orders {
+		ids[order.ID] = true
+		total += order.Amount
+	}
+	assert.Equal(t, map[uint]bool{1: true, 2: true, 3: true}, ids)
+	assert.Equal(t, float64(600), total)
+
+	assert.Empty(t, cache.GetByForeignKeysFlat(nil))
+}
+
+func TestRelatedCacheManagerForEachByForeignKey(t *testing.T) {
+	loader := &mockOrderLoader{orders: []models.Order{
+		{ID: 1, UserID: 1, Amount: 100},
+		{ID: 2, UserID: 1, Amount: 200},
+		{ID: 3, UserID: 1, Amount: 300},
+		{ID: 4, UserID: 2, Amount: 400},
+	}}
+	cache := NewRelatedCacheManager[models.Order](loader, 5*time.Minute)
+	assert.NoError(t, cache.Refresh())
+
+	var total float64
+	cache.ForEachByForeignKey(1, func(order models.Order) bool {
+		total += order.Amount
+		return true
+	})
+	assert.Equal(t, float64(600), total)
+
+	visited := 0
+	cache.ForEachByForeignKey(1, func(models.Order) bool {
+		visited++
+		return visited < 2
+	})
+	assert.Equal(t, 2, visited, "iteration stops when fn returns false")
+
+	cache.ForEachByForeignKey(9, func(models.Order) bool {
+		t.Fatal("unknown foreign key must not call fn")
+		return true
+	})
+}
+
+func newHotKeyOrderCache(b *testing.B) *RelatedCacheManager[models.Order] {
+	orders := make([]models.Order, 1000)
+	for i := range orders {
+		orders[i] = models.Order{ID: uint(i + 1), UserID: uint(i%10 + 1), Amount: 100}
+	}
+	cache := NewRelatedCacheManager[models.Order](&mockOrderLoader{orders: orders}, time.Hour)
+	if err := cache.Refresh(); err != nil {
+		b.Fatal(err)
+	}
+	return cache
+}
+
+func BenchmarkRelatedCacheManagerGetByForeignKey(b *testing.B) {
+	cache := newHotKeyOrderCache(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var total float64
+		for _, order := range cache.GetByForeignKey(1) {
+			total += order.Amount
+		}
+	}
+}
+
+func BenchmarkRelatedCacheManagerForEachByForeignKey(b *testing.B) {
+	cache := newHotKeyOrderCache(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var total float64
+		cache.ForEachByForeignKey(1, func(order models.Order) bool {
+			total += order.Amount
+			return true
+		})
+	}
+}
+
+func TestRelatedCacheManagerWithSkipZeroForeignKey(t *testing.T) {
+	loader := &mockOrderLoader{orders: []models.Order{
+		{ID: 1, UserID: 1, Amount: 100},
+		{ID: 2, UserID: 0, Amount: 200},
+		{ID: 3, UserID: 0, Amount: 300},
+	}}
+
+	t.Run("zero keys are indexed by default", func(t *testing.T) {
+		cache := NewRelatedCacheManager[models.Order](loader, 5*time.Minute)
+		assert.NoError(t, cache.Refresh())
+		assert.Len(t, cache.GetByForeignKey(0), 2)
+		assert.Empty(t, cache.Orphans())
+	})
+
+	cache := NewRelatedCacheManager[models.Order](loader, 5*time.Minute).WithSkipZeroForeignKey(true)
+	assert.NoError(t, cache.Refresh())
+	assert.Empty(t, cache.GetByForeignKey(0))
+	assert.Len(t, cache.GetByForeignKey(1), 1)
+	assert.ElementsMatch(t, []uint{1, 2, 3}, cache.Keys(), "orphans stay cached")
+
+	orphans := cache.Orphans()
+	assert.Len(t, orphans, 2)
+	for _, order := range orphans {
+		assert.Zero(t, order.UserID)
+	}
+	_, ok := cache.Get(2)
+	assert.True(t, ok)
+
+	t.Run("toggling moves cached orphans", func(t *testing.T) {
+		cache.WithSkipZeroForeignKey(false)
+		assert.Len(t, cache.GetByForeignKey(0), 2)
+		assert.Empty(t, cache.Orphans())
+		cache.WithSkipZeroForeignKey(true)
+		assert.Empty(t, cache.GetByForeignKey(0))
+		assert.Len(t, cache.Orphans(), 2)
 	})
 }
+
+func TestRelatedCacheManagerDeleteMany(t *testing.T) {
+	loader := &mockOrderLoader{orders: []models.Order{
+		{ID: 1, UserID: 1, Amount: 100},
+		{ID: 2, UserID: 1, Amount: 200},
+		{ID: 3, UserID: 2, Amount: 300},
+		{ID: 4, UserID: 0, Amount: 400},
+	}}
+	cache := NewRelatedCacheManager[models.Order](loader, 5*time.Minute).WithSkipZeroForeignKey(true)
+	assert.NoError(t, cache.Refresh())
+
+	assert.Equal(t, 3, cache.DeleteMany([]uint{1, 3, 4, 9, 3}))
+	assert.Equal(t, []uint{2}, cache.Keys())
+	assert.Len(t, cache.GetByForeignKey(1), 1)
+	assert.Empty(t, cache.Orphans())
+
+	counts := cache.CountByForeignKey()
+	assert.Equal(t, map[uint]int{1: 1}, counts, "emptied groups are dropped")
+	_, indexed := cache.fkIndex[2]
+	assert.False(t, indexed)
+}
+
+func TestRelatedCacheManagerCountByForeignKey(t *testing.T) {
+	loader := &mockOrderLoader{orders: []models.Order{
+		{ID: 1, UserID: 1, Amount: 100},
+		{ID: 2, UserID: 1, Amount: 200},
+		{ID: 3, UserID: 2, Amount: 300},
+		{ID: 4, UserID: 3, Amount: 400},
+		{ID: 5, UserID: 1, Amount: 500},
+	}}
+	cache := NewRelatedCacheManager[models.Order](loader, 5*time.Minute)
+	assert.NoError(t, cache.Refresh())
+	assert.Equal(t, map[uint]int{1: 3, 2: 1, 3: 1}, cache.CountByForeignKey())
+
+	loader.orders = loader.orders[:2]
+	assert.NoError(t, cache.Refresh())
+	assert.Equal(t, map[uint]int{1: 2}, cache.CountByForeignKey())
+}
+
+func TestCacheManagerAsRelated(t *testing.T) {
+	loader := &mockOrderLoader{orders: []models.Order{
+		{ID: 1, UserID: 1, Amount: 100},
+		{ID: 2, UserID: 1, Amount: 200},
+		{ID: 3, UserID: 2, Amount: 300},
+	}}
+	orders := NewCacheManager[models.Order](loader).WithTTL(5 * time.Minute)
+	assert.NoError(t, orders.Refresh())
+
+	// Later loads must not be needed to serve the related view
+	loader.err = errors.New("database unavailable")
+	related := orders.AsRelated(func(o models.Order) uint { return o.UserID })
+
+	assert.Len(t, related.GetByForeignKey(1), 2)
+	assert.Len(t, related.GetByForeignKey(2), 1)
+	order, ok := related.Get(3)
+	assert.True(t, ok)
+	assert.Equal(t, float64(300), order.Amount)
+
+	t.Run("keys by any extractor", func(t *testing.T) {
+		byAmount := orders.AsRelated(func(o models.Order) uint { return uint(o.Amount) / 200 })
+		assert.Len(t, byAmount.GetByForeignKey(0), 1)
+		assert.Len(t, byAmount.GetByForeignKey(1), 2)
+	})
+
+	t.Run("refresh uses the shared loader", func(t *testing.T) {
+		assert.Error(t, related.Refresh())
+		loader.err = nil
+		loader.orders = append(loader.orders, models.Order{ID: 4, UserID: 2, Amount: 400})
+		assert.NoError(t, related.Refresh())
+		assert.Len(t, related.GetByForeignKey(2), 2)
+	})
+}
+
+func TestRelatedCacheManagerForeignKeysMatching(t *testing.T) {
+	loader := &mockOrderLoader{orders: []models.Order{
+		{ID: 1, UserID: 1, Amount: 100},
+		{ID: 2, UserID: 1, Amount: 800},
+		{ID: 3, UserID: 2, Amount: 300},
+		{ID: 4, UserID: 3, Amount: 900},
+		{ID: 5, UserID: 3, Amount: 950},
+	}}
+	cache := NewRelatedCacheManager[models.Order](loader, 5*time.Minute)
+	assert.NoError(t, cache.Refresh())
+
+	highValue := NumberFieldCondition[models.Order, float64]{
+		FieldExtractor: func(o models.Order) float64 { return o.Amount },
+		Value:          500,
+		Operation:      "gt",
+	}
+	assert.Equal(t, []uint{1, 3}, cache.ForeignKeysMatching(highValue))
+
+	highValue.Value = 1000
+	assert.Empty(t, cache.ForeignKeysMatching(highValue))
+}
+
+func TestRelatedCacheManagerZeroTTL(t *testing.T) {
+	loader := &mockOrderLoader{orders: []models.Order{
+		{ID: 1, UserID: 1, Amount: 100},
+		{ID: 2, UserID: 2, Amount: 200},
+	}}
+	cache := NewRelatedCacheManager[models.Order](loader, 0)
+	assert.NoError(t, cache.Refresh())
+
+	time.Sleep(5 * time.Millisecond)
+	assert.Len(t, cache.GetAll(), 2, "a zero TTL never expires")
+	assert.Len(t, cache.GetByForeignKey(1), 1)
+}
+
+func TestRelatedCacheManagerWithTTL(t *testing.T) {
+	loader := &mockOrderLoader{orders: []models.Order{{ID: 1, UserID: 1, Amount: 100}}}
+	cache := NewRelatedCacheManager[models.Order](loader, 0).WithTTL(time.Millisecond)
+	assert.NoError(t, cache.Refresh())
+
+	time.Sleep(5 * time.Millisecond)
+	assert.Nil(t, cache.GetAll(), "the builder TTL applies")
+
+	cache.WithTTL(0)
+	assert.Len(t, cache.GetAll(), 1, "a zero TTL never expires")
+}
+
+func TestRelatedCacheManagerGetByForeignKeys(t *testing.T) {
+	loader := &mockOrderLoader{orders: []models.Order{
+		{ID: 1, UserID: 1, Amount: 100},
+		{ID: 2, UserID: 2, Amount: 200},
+		{ID: 3, UserID: 1, Amount: 300},
+	}}
+	cache := NewRelatedCacheManager[models.Order](loader, 5*time.Minute).
+		WithDeterministicOrder(func(a, b models.Order) bool { return a.ID < b.ID })
+	assert.NoError(t, cache.Refresh())
+
+	groups := cache.GetByForeignKeys(1, 2, 9)
+	assert.Len(t, groups, 3)
+	assert.Equal(t, []uint{1, 3}, ids(groups[1]))
+	assert.Equal(t, []uint{2}, ids(groups[2]))
+	assert.Empty(t, groups[9], "unknown keys map to an empty slice")
+	assert.NotNil(t, groups[9])
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// WithGroupLoader sets a function that loads the items of a single foreign
+// key group, e.g. a GormLoader with WithCondition("user_id = ?", fkID). It
+// enables RefreshForeignKey and makes GetByForeignKey read-through: a group
+// older than the TTL is reloaded on access, which bounds the staleness of
+// each group without reloading the whole cache.
+func (rcm *RelatedCacheManager[T]) WithGroupLoader(load func(fkID uint) ([]T, error)) *RelatedCacheManager[T] {
+	rcm.mu.Lock()
+	defer rcm.mu.Unlock()
+
+	rcm.groupLoader = load
+	return rcm
+}
+
+// RefreshForeignKey reloads a single foreign key group and replaces its items.
+// The load runs without holding the lock, so reads of other groups are not
+// blocked. If the load or the enrich hook fails the group is left unchanged.
+func (rcm *RelatedCacheManager[T]) RefreshForeignKey(fkID uint) error {
+	rcm.mu.RLock()
+	load, enrich := rcm.groupLoader, rcm.enrich
+	rcm.mu.RUnlock()
+	if load == nil {
+		return ErrNoGroupLoader
+	}
+
+	items, err := rcm.fetch(func() ([]T, error) {
+		items, err := load(fkID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load foreign key group %d: %w", fkID, err)
+		}
+		return items, nil
+	}, enrich)
+	if err != nil {
+		return err
+	}
+
+	rcm.mu.Lock()
+	defer rcm.mu.Unlock()
+
+	group := rcm.fkIndex[fkID]
+	if fkID == 0 && rcm.skipZeroFK {
+		// The items without a foreign key are kept as orphans
+		group, rcm.orphans = rcm.orphans, nil
+	}
+	for _, pk := range group {
+		rcm.unindexNamed(pk, rcm.data[pk])
+		delete(rcm.data, pk)
+	}
+	delete(rcm.fkIndex, fkID)
+	for _, item := range items {
+		pk := item.GetID()
+		if old, exists := rcm.data[pk]; exists {
+			// The item moved here from another group
+			rcm.unindex(pk, old)
+		}
+		rcm.data[pk] = item
+		rcm.indexNamed(pk, item)
+		fk := rcm.fkFunc(item)
+		if fk == 0 && rcm.skipZeroFK {
+			rcm.orphans = append(rcm.orphans, pk)
+			continue
+		}
+		rcm.fkIndex[fk] = append(rcm.fkIndex[fk], pk)
+	}
+
+	if rcm.groupFetch == nil {
+		rcm.groupFetch = make(map[uint]time.Time)
+	}
+	rcm.groupFetch[fkID] = time.Now()
+	return nil
+}
+
+// getGroupReadThrough serves GetByForeignKey when a group loader is set. A
+// load error yields nil like an expired cache; callers that need the error
+// call RefreshForeignKey themselves.
+func (rcm *RelatedCacheManager[T]) getGroupReadThrough(fkID uint) []T {
+	rcm.mu.RLock()
+	if rcm.groupFresh(fkID) {
+		defer rcm.mu.RUnlock()
+		return rcm.groupItems(fkID)
+	}
+	rcm.mu.RUnlock()
+
+	if err := rcm.RefreshForeignKey(fkID); err != nil {
+		return nil
+	}
+
+	rcm.mu.RLock()
+	defer rcm.mu.RUnlock()
+	return rcm.groupItems(fkID)
+}
+
+// groupFresh reports whether a group was loaded, by a full or a group
+// refresh, within the TTL. Callers hold the lock.
+func (rcm *RelatedCacheManager[T]) groupFresh(fkID uint) bool {
+	fetched := rcm.lastFetch
+	if t := rcm.groupFetch[fkID]; t.After(fetched) {
+		fetched = t
+	}
+	return !fetched.IsZero() && (rcm.ttl <= 0 || time.Since(fetched) <= rcm.ttl)
+}
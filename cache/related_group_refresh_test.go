@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// groupOrderLoader serves single foreign key groups from a fixed order set
+// and records which groups were loaded
+type groupOrderLoader struct {
+	orders []models.Order
+	loads  []uint
+	err    error
+}
+
+func (g *groupOrderLoader) load(fkID uint) ([]models.Order, error) {
+	g.loads = append(g.loads, fkID)
+	if g.err != nil {
+		return nil, g.err
+	}
+	var group []models.Order
+	for _, o := range g.orders {
+		if o.UserID == fkID {
+			group = append(group, o)
+		}
+	}
+	return group, nil
+}
+
+func TestRelatedCacheManagerGroupReadThrough(t *testing.T) {
+	orders := []models.Order{
+		{ID: 1, UserID: 1, Amount: 100},
+		{ID: 2, UserID: 1, Amount: 200},
+		{ID: 3, UserID: 2, Amount: 300},
+	}
+	groups := &groupOrderLoader{orders: orders}
+	cache := NewRelatedCacheManager[models.Order](&mockOrderLoader{orders: orders}, time.Minute).
+		WithGroupLoader(groups.load)
+	assert.NoError(t, cache.Refresh())
+
+	assert.Len(t, cache.GetByForeignKey(1), 2)
+	assert.Empty(t, groups.loads, "fresh groups are served from the cache")
+
+	t.Run("only the stale group is reloaded", func(t *testing.T) {
+		cache.lastFetch = time.Now().Add(-2 * time.Minute)
+		groups.orders = []models.Order{
+			{ID: 1, UserID: 1, Amount: 150},
+			{ID: 3, UserID: 2, Amount: 350},
+		}
+
+		got := cache.GetByForeignKey(1)
+		assert.Equal(t, []uint{1}, groups.loads)
+		assert.Equal(t, []models.Order{{ID: 1, UserID: 1, Amount: 150}}, got)
+		_, exists := cache.data[2]
+		assert.False(t, exists, "items gone from the group are dropped")
+		assert.Equal(t, float64(300), cache.data[3].Amount, "other groups keep their data")
+
+		cache.GetByForeignKey(1)
+		assert.Equal(t, []uint{1}, groups.loads, "a reloaded group is fresh again")
+	})
+
+	t.Run("a failed group load returns nil", func(t *testing.T) {
+		groups.err = errors.New("db down")
+		assert.Nil(t, cache.GetByForeignKey(2))
+		assert.ErrorIs(t, cache.RefreshForeignKey(2), groups.err)
+	})
+}
+
+func TestRelatedCacheManagerRefreshForeignKey(t *testing.T) {
+	cache := NewRelatedCacheManager[models.Order](&mockOrderLoader{orders: []models.Order{
+		{ID: 1, UserID: 1, Amount: 100},
+		{ID: 2, UserID: 2, Amount: 200},
+	}}, time.Minute)
+	assert.ErrorIs(t, cache.RefreshForeignKey(1), ErrNoGroupLoader)
+	assert.NoError(t, cache.Refresh())
+
+	// Order 2 moved from user 2 to user 1
+	groups := &groupOrderLoader{orders: []models.Order{
+		{ID: 1, UserID: 1, Amount: 100},
+		{ID: 2, UserID: 1, Amount: 200},
+	}}
+	cache.WithGroupLoader(groups.load)
+	assert.NoError(t, cache.RefreshForeignKey(1))
+	assert.Len(t, cache.GetByForeignKey(1), 2)
+	assert.Empty(t, cache.fkIndex[2])
+	assert.Equal(t, []uint{1}, groups.loads)
+}
+
+func TestRelatedCacheManagerRefreshOrphanGroup(t *testing.T) {
+	orders := []models.Order{
+		{ID: 1, UserID: 1, Amount: 100},
+		{ID: 2, UserID: 0, Amount: 200},
+	}
+	cache := NewRelatedCacheManager[models.Order](&mockOrderLoader{orders: orders}, 0).
+		WithSkipZeroForeignKey(true)
+	assert.NoError(t, cache.Refresh())
+
+	groups := &groupOrderLoader{orders: []models.Order{
+		{ID: 2, UserID: 0, Amount: 250},
+		{ID: 3, UserID: 0, Amount: 300},
+	}}
+	cache.WithGroupLoader(groups.load)
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, cache.RefreshForeignKey(0))
+	}
+	assert.ElementsMatch(t, []uint{2, 3}, ids(cache.Orphans()), "reloaded orphans replace the old ones")
+	assert.Empty(t, cache.fkIndex[0])
+	assert.Len(t, cache.GetAll(), 3)
+
+	groups.orders = groups.orders[1:]
+	assert.NoError(t, cache.RefreshForeignKey(0))
+	assert.Equal(t, []uint{3}, ids(cache.Orphans()), "an orphan missing from the reload is dropped")
+}
+
+func TestRelatedCacheManagerWithGroupLoaderConcurrent(t *testing.T) {
+	cache := NewRelatedCacheManager[models.Order](&mockOrderLoader{orders: []models.Order{{ID: 1, UserID: 1}}}, 0)
+	assert.NoError(t, cache.Refresh())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cache.WithGroupLoader(func(fkID uint) ([]models.Order, error) {
+			return []models.Order{{ID: 1, UserID: fkID}}, nil
+		})
+	}()
+	for i := 0; i < 100; i++ {
+		assert.Len(t, cache.GetByForeignKey(1), 1)
+	}
+	<-done
+}
@@ -0,0 +1,83 @@
+package cache
+
+import "slices"
+
+// AddIndex adds a named index that groups items by the key fn returns, next
+// to the foreign key index, e.g. to look orders up by product as well as by
+// user. The index is built from the cached items right away and maintained
+// on every refresh and deletion. Adding an index under an existing name
+// replaces it.
+func (rcm *RelatedCacheManager[T]) AddIndex(name string, fn func(T) uint) *RelatedCacheManager[T] {
+	rcm.mu.Lock()
+	defer rcm.mu.Unlock()
+
+	if rcm.indexFuncs == nil {
+		rcm.indexFuncs = make(map[string]func(T) uint)
+	}
+	rcm.indexFuncs[name] = fn
+	rcm.rebuildIndexes()
+	return rcm
+}
+
+// GetByIndex retrieves the items whose key in the named index equals key,
+// like GetByForeignKey does for the foreign key index. An unknown index
+// yields nil.
+func (rcm *RelatedCacheManager[T]) GetByIndex(name string, key uint) []T {
+	rcm.mu.RLock()
+	defer rcm.mu.RUnlock()
+
+	if rcm.isExpired() {
+		return nil
+	}
+
+	index, ok := rcm.indexes[name]
+	if !ok {
+		return nil
+	}
+	pks := index[key]
+	result := make([]T, 0, len(pks))
+	for _, pk := range pks {
+		if item, exists := rcm.data[pk]; exists {
+			result = append(result, item)
+		}
+	}
+	return sortItems(result, rcm.less)
+}
+
+// rebuildIndexes recomputes every named index from the data. Callers hold
+// the write lock.
+func (rcm *RelatedCacheManager[T]) rebuildIndexes() {
+	if len(rcm.indexFuncs) == 0 {
+		rcm.indexes = nil
+		return
+	}
+	rcm.indexes = make(map[string]map[uint][]uint, len(rcm.indexFuncs))
+	for name := range rcm.indexFuncs {
+		rcm.indexes[name] = make(map[uint][]uint)
+	}
+	for pk, item := range rcm.data {
+		rcm.indexNamed(pk, item)
+	}
+}
+
+// indexNamed adds an item to every named index. Callers hold the write lock.
+func (rcm *RelatedCacheManager[T]) indexNamed(pk uint, item T) {
+	for name, fn := range rcm.indexFuncs {
+		key := fn(item)
+		rcm.indexes[name][key] = append(rcm.indexes[name][key], pk)
+	}
+}
+
+// unindexNamed removes an item from every named index, dropping keys that
+// become empty. Callers hold the write lock.
+func (rcm *RelatedCacheManager[T]) unindexNamed(pk uint, item T) {
+	for name, fn := range rcm.indexFuncs {
+		key := fn(item)
+		pks := slices.DeleteFunc(rcm.indexes[name][key], func(id uint) bool { return id == pk })
+		if len(pks) > 0 {
+			rcm.indexes[name][key] = pks
+		} else {
+			delete(rcm.indexes[name], key)
+		}
+	}
+}
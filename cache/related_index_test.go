@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// orderLine is an order position, which is not keyed to a user
+type orderLine struct {
+	ID        uint
+	OrderID   uint
+	ProductID uint
+}
+
+func (l orderLine) GetID() uint { return l.ID }
+
+type mockOrderLineLoader struct {
+	lines []orderLine
+}
+
+func (m *mockOrderLineLoader) Load() ([]orderLine, error) {
+	return m.lines, nil
+}
+
+func TestNewIndexedCacheManager(t *testing.T) {
+	loader := &mockOrderLineLoader{lines: []orderLine{
+		{ID: 1, OrderID: 1, ProductID: 10},
+		{ID: 2, OrderID: 1, ProductID: 20},
+		{ID: 3, OrderID: 2, ProductID: 10},
+	}}
+	cache := NewIndexedCacheManager[orderLine](loader, time.Minute,
+		func(l orderLine) uint { return l.OrderID }).
+		AddIndex("product", func(l orderLine) uint { return l.ProductID }).
+		WithDeterministicOrder(func(a, b orderLine) bool { return a.ID < b.ID })
+	assert.NoError(t, cache.Refresh())
+
+	assert.Len(t, cache.GetByForeignKey(1), 2)
+	assert.Equal(t, []orderLine{loader.lines[0], loader.lines[2]}, cache.GetByIndex("product", 10))
+	assert.Empty(t, cache.GetByIndex("product", 30))
+	assert.Nil(t, cache.GetByIndex("missing", 10))
+
+	t.Run("deletions update the named indexes", func(t *testing.T) {
+		cache.DeleteMany([]uint{3})
+		assert.Equal(t, []orderLine{loader.lines[0]}, cache.GetByIndex("product", 10))
+		cache.DeleteMany([]uint{2})
+		assert.NotContains(t, cache.indexes["product"], uint(20))
+	})
+
+	t.Run("an index added later covers cached items", func(t *testing.T) {
+		assert.NoError(t, cache.Refresh())
+		cache.AddIndex("order", func(l orderLine) uint { return l.OrderID })
+		assert.Len(t, cache.GetByIndex("order", 1), 2)
+	})
+}
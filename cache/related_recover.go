@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// WithPanicRecovery makes the related cache recover panics in the loader,
+// the group loader, the enrich hook and the conditions passed to Query,
+// GetByForeignKeyWhere and ForeignKeysMatching instead of crashing the
+// process. A recovered panic becomes an error wrapping ErrCallbackPanic: a
+// panicking loader or hook fails the refresh with it and the cache keeps
+// its contents, while a panicking condition counts as not matching and its
+// error is kept in RecentErrors. The foreign key extractor, AddIndex funcs,
+// the order func and the ForEachByForeignKey callback are not recovered.
+func (rcm *RelatedCacheManager[T]) WithPanicRecovery(enabled bool) *RelatedCacheManager[T] {
+	rcm.recoverPanics = enabled
+	return rcm
+}
+
+// RecentErrors returns the most recent condition panics recovered with
+// WithPanicRecovery, oldest first
+func (rcm *RelatedCacheManager[T]) RecentErrors() []TimestampedError {
+	rcm.mu.RLock()
+	defer rcm.mu.RUnlock()
+	return rcm.errorLog.list()
+}
+
+// guard returns a callbackGuard for one query. report records its panic and
+// must be called without the lock once the query is done.
+func (rcm *RelatedCacheManager[T]) guard() (g *callbackGuard, report func()) {
+	g = &callbackGuard{enabled: rcm.recoverPanics}
+	return g, func() {
+		if g.err != nil {
+			rcm.mu.Lock()
+			defer rcm.mu.Unlock()
+			rcm.errorLog.add(rcm.named(g.err), time.Now())
+		}
+	}
+}
+
+// fetch runs a load and the enrich hook on its result, turning a panic of
+// either into an error under WithPanicRecovery. It touches no cache state.
+func (rcm *RelatedCacheManager[T]) fetch(load func() ([]T, error), enrich func(items []T) error) (items []T, err error) {
+	g := callbackGuard{enabled: rcm.recoverPanics}
+	if !g.call("loader", func() { items, err = load() }) {
+		return nil, g.err
+	}
+	if err != nil {
+		return nil, err
+	}
+	if enrich != nil {
+		if !g.call("enrich hook", func() { err = enrich(items) }) {
+			return nil, g.err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to enrich items: %w", err)
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelatedCacheManagerPanicRecovery(t *testing.T) {
+	orders := []models.Order{
+		{ID: 1, UserID: 1, Amount: 10},
+		{ID: 2, UserID: 1, Amount: 20},
+		{ID: 3, UserID: 2, Amount: 30},
+	}
+	newCache := func() *RelatedCacheManager[models.Order] {
+		cache := NewRelatedCacheManager[models.Order](&mockOrderLoader{orders: orders}, 0).
+			WithPanicRecovery(true).
+			WithDeterministicOrder(func(a, b models.Order) bool { return a.ID < b.ID })
+		assert.NoError(t, cache.Refresh())
+		return cache
+	}
+	// over15 panics on order 2
+	over15 := NumberFieldCondition[models.Order, float64]{
+		FieldExtractor: func(o models.Order) float64 {
+			if o.ID == 2 {
+				panic("extractor bug")
+			}
+			return o.Amount
+		},
+		Value:     15,
+		Operation: "gt",
+	}
+	assertRecorded := func(t *testing.T, cache *RelatedCacheManager[models.Order], n int) {
+		t.Helper()
+		errs := cache.RecentErrors()
+		if assert.Len(t, errs, n) {
+			assert.ErrorIs(t, errs[n-1].Err, ErrCallbackPanic)
+			assert.Contains(t, errs[n-1].Err.Error(), "extractor bug")
+		}
+	}
+
+	t.Run("conditions count as not matching", func(t *testing.T) {
+		cache := newCache()
+		assert.Equal(t, []models.Order{orders[2]}, cache.Query(over15))
+		assertRecorded(t, cache, 1)
+		assert.Empty(t, cache.GetByForeignKeyWhere(1, over15))
+		assertRecorded(t, cache, 2)
+		assert.Equal(t, []uint{2}, cache.ForeignKeysMatching(over15))
+		assertRecorded(t, cache, 3)
+
+		// The lock was released: refreshes still go through
+		assert.NoError(t, cache.Refresh())
+	})
+
+	t.Run("a panicking loader fails the refresh", func(t *testing.T) {
+		cache := newCache()
+		cache.loader = loaderFunc[models.Order](func() ([]models.Order, error) { panic("driver bug") })
+		assert.ErrorIs(t, cache.Refresh(), ErrCallbackPanic)
+		assert.ErrorIs(t, RefreshTogether(cache), ErrCallbackPanic)
+		assert.Len(t, cache.GetAll(), 3, "the cache keeps its contents")
+	})
+
+	t.Run("a panicking enrich hook fails the refresh", func(t *testing.T) {
+		cache := newCache().WithEnrich(func([]models.Order) error { panic("enrich bug") })
+		assert.ErrorIs(t, cache.Refresh(), ErrCallbackPanic)
+		assert.Len(t, cache.GetAll(), 3)
+	})
+
+	t.Run("a panicking group loader fails the group refresh", func(t *testing.T) {
+		cache := newCache().WithGroupLoader(func(uint) ([]models.Order, error) { panic("group bug") })
+		assert.ErrorIs(t, cache.RefreshForeignKey(1), ErrCallbackPanic)
+		assert.Len(t, cache.GetByForeignKey(1), 2, "the group is left unchanged")
+	})
+}
+
+func TestRelatedCacheManagerPanicsByDefault(t *testing.T) {
+	cache := NewRelatedCacheManager[models.Order](&mockOrderLoader{orders: []models.Order{{ID: 1}}}, 0)
+	assert.NoError(t, cache.Refresh())
+	assert.Panics(t, func() {
+		cache.Query(NumberFieldCondition[models.Order, uint]{
+			FieldExtractor: func(models.Order) uint { panic("bug") },
+			Operation:      "eq",
+		})
+	})
+}
@@ -0,0 +1,49 @@
+package cache
+
+import "context"
+
+// WithStaleWhileRevalidate keeps serving the cached data after the TTL
+// elapses instead of failing reads. The first read that observes the
+// expiry starts a background refresh, which like RefreshContext loads
+// without holding the lock; until it completes reads keep getting the stale
+// data, flagged by EntryMeta.Stale in GetWithMeta. The background refresh
+// is deduplicated with Refresh: it joins a Refresh in flight instead of
+// loading again, and a Refresh called meanwhile waits for it and shares its
+// result. A failed refresh is kept in
+// RecentErrors and retried on a later read. Enabling it sets both expiry
+// policies to ExpiryServeStale.
+func (cm *CacheManager[T]) WithStaleWhileRevalidate(enabled bool) *CacheManager[T] {
+	cm.revalidate = enabled
+	if enabled {
+		cm.getPolicy = ExpiryServeStale
+		cm.listPolicy = ExpiryServeStale
+	}
+	return cm
+}
+
+// startRevalidate starts a background refresh unless one is running. It is
+// called by reads holding the read lock; the refresh takes the write lock
+// in its own goroutine, only to swap in the loaded data.
+func (cm *CacheManager[T]) startRevalidate() {
+	if cm.lastFetch.IsZero() || !cm.revalidating.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer cm.revalidating.Store(false)
+		var result refreshChanges[T]
+		leader, err := cm.flight.do(func() error {
+			var err error
+			result, err = cm.loadAndSwap(context.Background())
+			return err
+		})
+		if err != nil {
+			if cm.logger != nil {
+				cm.logf("cache revalidation failed: %v", err)
+			}
+			return
+		}
+		if leader {
+			cm.fireRefresh(result)
+		}
+	}()
+}
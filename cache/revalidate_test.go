@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingUserLoader counts loads and holds each one until release is closed
+type blockingUserLoader struct {
+	countingUserLoader
+	release chan struct{}
+}
+
+func (l *blockingUserLoader) Load() ([]models.User, error) {
+	<-l.release
+	return l.countingUserLoader.Load()
+}
+
+func TestCacheManagerStaleWhileRevalidate(t *testing.T) {
+	loader := &blockingUserLoader{release: make(chan struct{})}
+	cache := NewCacheManager[models.User](loader).WithTTL(time.Minute).WithStaleWhileRevalidate(true)
+	close(loader.release)
+	assert.NoError(t, cache.Refresh())
+	loader.release = make(chan struct{})
+	loader.loads.Store(0)
+
+	cache.mu.Lock()
+	cache.lastFetch = time.Now().Add(-2 * time.Minute)
+	cache.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user, err := cache.Get(1)
+			assert.NoError(t, err, "stale data is served while revalidating")
+			assert.Equal(t, "John", user.Name)
+			assert.Len(t, cache.GetAll(), 1)
+		}()
+	}
+	wg.Wait()
+
+	_, meta, ok := cache.GetWithMeta(1)
+	assert.True(t, ok)
+	assert.True(t, meta.Stale)
+
+	close(loader.release)
+	assert.Eventually(t, func() bool { return !cache.IsStale() }, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool { return !cache.revalidating.Load() }, time.Second, time.Millisecond)
+	assert.Equal(t, int32(1), loader.loads.Load(), "a single background refresh")
+
+	_, meta, ok = cache.GetWithMeta(1)
+	assert.True(t, ok)
+	assert.False(t, meta.Stale)
+}
+
+func TestCacheManagerRevalidateSharesRefresh(t *testing.T) {
+	loader := &blockingUserLoader{release: make(chan struct{})}
+	cache := NewCacheManager[models.User](loader).WithTTL(time.Minute).WithStaleWhileRevalidate(true)
+	close(loader.release)
+	assert.NoError(t, cache.Refresh())
+	loader.release = make(chan struct{})
+	loader.loads.Store(0)
+
+	cache.mu.Lock()
+	cache.lastFetch = time.Now().Add(-2 * time.Minute)
+	cache.mu.Unlock()
+
+	_, err := cache.Get(1) // starts the background refresh
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		cache.flight.mu.Lock()
+		defer cache.flight.mu.Unlock()
+		return cache.flight.call != nil
+	}, time.Second, time.Millisecond, "the revalidation is in flight")
+
+	refreshed := make(chan error, 1)
+	go func() { refreshed <- cache.Refresh() }()
+	time.Sleep(20 * time.Millisecond) // let Refresh join the flight
+
+	close(loader.release)
+	assert.NoError(t, <-refreshed)
+	assert.Eventually(t, func() bool { return !cache.revalidating.Load() }, time.Second, time.Millisecond)
+	assert.Equal(t, int32(1), loader.loads.Load(), "Refresh and the revalidation share one load")
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"sort"
+	"strings"
+)
+
+// searchScore ranks how well value matches term, lower is better:
+//
+//	0                    value equals term
+//	1                    value starts with term
+//	2 + byte position    term appears later in value
+//
+// The second result is false when value does not contain term. Matching is
+// case-sensitive, like the "contains" operation of StringFieldCondition.
+func searchScore(value, term string) (int, bool) {
+	pos := strings.Index(value, term)
+	switch {
+	case pos < 0:
+		return 0, false
+	case value == term:
+		return 0, true
+	case pos == 0:
+		return 1, true
+	default:
+		return 2 + pos, true
+	}
+}
+
+// QuerySearch returns the items whose field contains term, best matches
+// first: exact matches, then prefix matches, then substring matches by how
+// early the term appears. Ties are ordered by ID.
+func (cm *CacheManager[T]) QuerySearch(field func(T) string, term string) []T {
+	defer cm.notifyExpire()
+
+	type scored struct {
+		item  T
+		score int
+	}
+
+	g, report := cm.guard()
+	defer report()
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	if !cm.listAllowed() {
+		return unavailableList[T](cm)
+	}
+	matches := make([]scored, 0)
+	for _, item := range cm.data {
+		var value string
+		if !g.call("search field func", func() { value = field(item) }) {
+			continue
+		}
+		if score, ok := searchScore(value, term); ok {
+			matches = append(matches, scored{item: item, score: score})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score < matches[j].score
+		}
+		return matches[i].item.GetID() < matches[j].item.GetID()
+	})
+
+	result := make([]T, len(matches))
+	for i, m := range matches {
+		result[i] = m.item
+	}
+	return result
+}
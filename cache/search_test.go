@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheManagerQuerySearch(t *testing.T) {
+	cache := NewCacheManager[models.User](&mockUserLoader{users: []models.User{
+		{ID: 1, Name: "Big John"},
+		{ID: 2, Name: "Johnny"},
+		{ID: 3, Name: "John"},
+		{ID: 4, Name: "Al John"},
+		{ID: 5, Name: "Jane"},
+	}})
+	assert.NoError(t, cache.Refresh())
+
+	byName := func(u models.User) string { return u.Name }
+	users := cache.QuerySearch(byName, "John")
+
+	names := make([]string, len(users))
+	for i, user := range users {
+		names[i] = user.Name
+	}
+	assert.Equal(t, []string{"John", "Johnny", "Al John", "Big John"}, names,
+		"exact before prefix before earlier substring")
+
+	assert.Empty(t, cache.QuerySearch(byName, "Bob"))
+
+	assert.Panics(t, func() {
+		cache.QuerySearch(func(models.User) string { panic("extractor bug") }, "John")
+	})
+	assert.NoError(t, cache.Refresh(), "a panicking field func releases the read lock")
+}
+
+func TestSearchScore(t *testing.T) {
+	exact, _ := searchScore("John", "John")
+	prefix, _ := searchScore("Johnny", "John")
+	middle, _ := searchScore("Big John", "John")
+	_, ok := searchScore("Jane", "John")
+
+	assert.Less(t, exact, prefix)
+	assert.Less(t, prefix, middle)
+	assert.False(t, ok)
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// ShardedCacheManager spreads a dataset across several CacheManagers so
+// that reads and writes of different IDs contend on different locks. A
+// single loader fills all shards at once; by default an item goes to shard
+// GetID() % N.
+type ShardedCacheManager[T Identifiable] struct {
+	shards    []*CacheManager[T]
+	loader    DataLoader[T]
+	shardFunc func(id uint) int
+}
+
+// NewShardedCacheManager creates a cache split into n shards, at least one
+func NewShardedCacheManager[T Identifiable](loader DataLoader[T], n int) *ShardedCacheManager[T] {
+	shards := make([]*CacheManager[T], max(1, n))
+	for i := range shards {
+		shards[i] = NewCacheManager[T](nil)
+	}
+	return &ShardedCacheManager[T]{
+		shards: shards,
+		loader: loader,
+		shardFunc: func(id uint) int {
+			return int(id % uint(len(shards)))
+		},
+	}
+}
+
+// WithTTL sets the TTL of every shard
+func (s *ShardedCacheManager[T]) WithTTL(ttl time.Duration) *ShardedCacheManager[T] {
+	for _, shard := range s.shards {
+		shard.WithTTL(ttl)
+	}
+	return s
+}
+
+// WithShardFunc replaces the modulo shard assignment, e.g. with a hash of
+// the ID when IDs are not uniformly distributed. Results outside [0, N) are
+// wrapped into range. Set it before the first Refresh: items already cached
+// are not moved.
+func (s *ShardedCacheManager[T]) WithShardFunc(shardFunc func(id uint) int) *ShardedCacheManager[T] {
+	s.shardFunc = shardFunc
+	return s
+}
+
+// shardIndex returns the index of the shard holding id
+func (s *ShardedCacheManager[T]) shardIndex(id uint) int {
+	n := len(s.shards)
+	return ((s.shardFunc(id) % n) + n) % n
+}
+
+// Get retrieves an item by ID from its shard
+func (s *ShardedCacheManager[T]) Get(id uint) (T, error) {
+	return s.shards[s.shardIndex(id)].Get(id)
+}
+
+// GetAll returns the items of all shards
+func (s *ShardedCacheManager[T]) GetAll() []T {
+	var result []T
+	for _, shard := range s.shards {
+		result = append(result, shard.GetAll()...)
+	}
+	return result
+}
+
+// ShardSizes returns the number of items held by each shard
+func (s *ShardedCacheManager[T]) ShardSizes() []int {
+	sizes := make([]int, len(s.shards))
+	for i, shard := range s.shards {
+		shard.mu.RLock()
+		sizes[i] = len(shard.data)
+		shard.mu.RUnlock()
+	}
+	return sizes
+}
+
+// Refresh loads the dataset once and replaces the contents of every shard,
+// so items the source dropped are removed. The shards are swapped one after
+// another, so a concurrent reader may briefly see some shards refreshed and
+// others not.
+func (s *ShardedCacheManager[T]) Refresh() error {
+	if s.loader == nil {
+		return ErrNoLoader
+	}
+	items, err := s.loader.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load sharded cache: %w", err)
+	}
+	parts := make([][]T, len(s.shards))
+	for _, item := range items {
+		i := s.shardIndex(item.GetID())
+		parts[i] = append(parts[i], item)
+	}
+	now := time.Now()
+	for i, shard := range s.shards {
+		result := shard.executeWithLock(false, func() interface{} {
+			return shard.swapData(parts[i], now, false)
+		}).(refreshChanges[T])
+		shard.fireRefresh(result)
+	}
+	return nil
+}
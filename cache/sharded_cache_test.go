@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardedCacheManager(t *testing.T) {
+	loader := &mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John"}, {ID: 2, Name: "Jane"}, {ID: 3, Name: "Bob"},
+	}}
+	cache := NewShardedCacheManager[models.User](loader, 2)
+	assert.NoError(t, cache.Refresh())
+
+	user, err := cache.Get(2)
+	assert.NoError(t, err)
+	assert.Equal(t, "Jane", user.Name)
+	assert.Len(t, cache.GetAll(), 3)
+	assert.Equal(t, []int{1, 2}, cache.ShardSizes(), "modulo by default")
+
+	_, err = cache.Get(4)
+	assert.Error(t, err)
+
+	loader.err = errors.New("database down")
+	assert.ErrorIs(t, cache.Refresh(), loader.err)
+	assert.Len(t, cache.GetAll(), 3, "a failed refresh keeps the data")
+	t.Run("a smaller load drops the missing items", func(t *testing.T) {
+		loader.err = nil
+		loader.users = loader.users[:1]
+		assert.NoError(t, cache.Refresh())
+		assert.Len(t, cache.GetAll(), 1)
+		assert.Equal(t, []int{0, 1}, cache.ShardSizes())
+		_, err := cache.Get(2)
+		assert.Error(t, err)
+	})
+
+	t.Run("without a loader", func(t *testing.T) {
+		assert.ErrorIs(t, NewShardedCacheManager[models.User](nil, 2).Refresh(), ErrNoLoader)
+	})
+}
+
+func TestShardedCacheManagerWithShardFunc(t *testing.T) {
+	// IDs that are all multiples of the shard count land in one shard
+	var users []models.User
+	for id := uint(4); id <= 400; id += 4 {
+		users = append(users, models.User{ID: id})
+	}
+	loader := &mockUserLoader{users: users}
+
+	modulo := NewShardedCacheManager[models.User](loader, 4)
+	assert.NoError(t, modulo.Refresh())
+	assert.Equal(t, []int{100, 0, 0, 0}, modulo.ShardSizes())
+
+	hashed := NewShardedCacheManager[models.User](loader, 4).WithShardFunc(func(id uint) int {
+		// Fibonacci hashing: the top bits of the product pick the shard
+		return int(uint64(id) * 0x9E3779B97F4A7C15 >> 62)
+	})
+	assert.NoError(t, hashed.Refresh())
+	sizes := hashed.ShardSizes()
+	assert.Less(t, slices.Max(sizes), 50, "the hash spreads the skewed IDs")
+	assert.Greater(t, slices.Min(sizes), 0)
+
+	for _, user := range users {
+		_, err := hashed.Get(user.ID)
+		assert.NoError(t, err)
+	}
+
+	t.Run("out of range shards wrap", func(t *testing.T) {
+		negative := NewShardedCacheManager[models.User](loader, 4).WithShardFunc(func(id uint) int {
+			return -int(id)
+		})
+		assert.NoError(t, negative.Refresh())
+		_, err := negative.Get(8)
+		assert.NoError(t, err)
+	})
+}
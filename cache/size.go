@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"reflect"
+	"time"
+)
+
+// entryOverhead is a rough per-entry cost of the map slot, the entry
+// metadata and the LRU bookkeeping
+const entryOverhead = 96
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// EstimatedSizeBytes returns an approximate size of the cached data. It
+// counts each item's inline size plus the strings, slices, maps and pointed
+// to values it references, and a fixed per-entry overhead. Data shared
+// between items through pointers is counted once per item, and allocator
+// and map growth slack is ignored, so treat the result as an estimate.
+func (cm *CacheManager[T]) EstimatedSizeBytes() int64 {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.memoryBudget > 0 {
+		return cm.sizeBytes
+	}
+	var total int64
+	for _, item := range cm.data {
+		total += estimateEntrySize(item)
+	}
+	return total
+}
+
+// estimateEntrySize returns the estimated size of a cached item including
+// the per-entry overhead
+func estimateEntrySize(item any) int64 {
+	v := reflect.ValueOf(item)
+	if !v.IsValid() {
+		return entryOverhead
+	}
+	return entryOverhead + int64(v.Type().Size()) + referencedSize(v, make(map[uintptr]bool))
+}
+
+// referencedSize returns the bytes referenced by v beyond its inline size.
+// visited guards against counting a pointer twice and against cycles.
+func referencedSize(v reflect.Value, visited map[uintptr]bool) int64 {
+	switch v.Kind() {
+	case reflect.String:
+		return int64(v.Len())
+	case reflect.Slice:
+		if v.IsNil() || visited[v.Pointer()] {
+			return 0
+		}
+		visited[v.Pointer()] = true
+		size := int64(v.Cap()) * int64(v.Type().Elem().Size())
+		for i := 0; i < v.Len(); i++ {
+			size += referencedSize(v.Index(i), visited)
+		}
+		return size
+	case reflect.Array:
+		var size int64
+		for i := 0; i < v.Len(); i++ {
+			size += referencedSize(v.Index(i), visited)
+		}
+		return size
+	case reflect.Map:
+		if v.IsNil() || visited[v.Pointer()] {
+			return 0
+		}
+		visited[v.Pointer()] = true
+		entry := int64(v.Type().Key().Size() + v.Type().Elem().Size())
+		size := int64(v.Len()) * entry
+		iter := v.MapRange()
+		for iter.Next() {
+			size += referencedSize(iter.Key(), visited) + referencedSize(iter.Value(), visited)
+		}
+		return size
+	case reflect.Pointer:
+		if v.IsNil() || visited[v.Pointer()] {
+			return 0
+		}
+		visited[v.Pointer()] = true
+		return int64(v.Type().Elem().Size()) + referencedSize(v.Elem(), visited)
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		elem := v.Elem()
+		return int64(elem.Type().Size()) + referencedSize(elem, visited)
+	case reflect.Struct:
+		// time.Time points at a shared *Location, do not charge it per item
+		if v.Type() == timeType {
+			return 0
+		}
+		var size int64
+		for i := 0; i < v.NumField(); i++ {
+			size += referencedSize(v.Field(i), visited)
+		}
+		return size
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Codec encodes and decodes cache snapshots
+type Codec interface {
+	Encode(w io.Writer, v any) error
+	Decode(r io.Reader, v any) error
+}
+
+// JSONCodec is the default snapshot codec
+type JSONCodec struct{}
+
+// Encode implements the Codec interface
+func (JSONCodec) Encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// Decode implements the Codec interface
+func (JSONCodec) Decode(r io.Reader, v any) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+// snapshot is the serialized form of a CacheManager
+type snapshot[T any] struct {
+	FetchedAt time.Time          `json:"fetched_at"`
+	Entries   []snapshotEntry[T] `json:"entries"`
+}
+
+type snapshotEntry[T any] struct {
+	Item     T         `json:"item"`
+	LoadedAt time.Time `json:"loaded_at"`
+}
+
+// WithCodec sets the codec used by Snapshot and LoadSnapshot
+func (cm *CacheManager[T]) WithCodec(codec Codec) *CacheManager[T] {
+	cm.codec = codec
+	return cm
+}
+
+// Snapshot writes the cached items, their load times and the last fetch
+// time to w using the configured codec
+func (cm *CacheManager[T]) Snapshot(w io.Writer) error {
+	cm.mu.RLock()
+	snap := snapshot[T]{
+		FetchedAt: cm.lastFetch,
+		Entries:   make([]snapshotEntry[T], 0, len(cm.data)),
+	}
+	for id, item := range cm.data {
+		entry := snapshotEntry[T]{Item: item, LoadedAt: cm.lastFetch}
+		if m := cm.meta[id]; m != nil {
+			entry.LoadedAt = m.loadedAt
+		}
+		snap.Entries = append(snap.Entries, entry)
+	}
+	cm.mu.RUnlock()
+
+	if err := cm.snapshotCodec().Encode(w, snap); err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshot replaces the cache contents with a snapshot written by
+// Snapshot. The restored cache keeps the snapshot's fetch time, so the TTL
+// keeps counting from the original load.
+func (cm *CacheManager[T]) LoadSnapshot(r io.Reader) error {
+	var snap snapshot[T]
+	if err := cm.snapshotCodec().Decode(r, &snap); err != nil {
+		return fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cm.clearData(len(snap.Entries))
+	for _, entry := range snap.Entries {
+		cm.putItem(entry.Item, entry.LoadedAt)
+	}
+	cm.markFetched(snap.FetchedAt)
+	return nil
+}
+
+// Close marks the cache closed, stops auto-refresh and waits for a Refresh
+// in flight to finish, so no Refresh swaps in data after Close returns.
+// Further refreshes return ErrCacheClosed while reads keep serving the
+// current data. Close is idempotent; it must not be called from a loader or
+// from a hook of an auto-refresh, which would wait for itself.
+func (cm *CacheManager[T]) Close() {
+	if !cm.closed.Swap(true) {
+		cm.events.close()
+	}
+	cm.stopAutoRefresh()
+	cm.flight.wait()
+}
+
+// DrainAndClose closes the cache and writes a final snapshot to w. Any
+// refresh already in progress completes before the snapshot is taken.
+func (cm *CacheManager[T]) DrainAndClose(w io.Writer) error {
+	cm.Close()
+	return cm.Snapshot(w)
+}
+
+func (cm *CacheManager[T]) snapshotCodec() Codec {
+	if cm.codec == nil {
+		return JSONCodec{}
+	}
+	return cm.codec
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheManagerSnapshot(t *testing.T) {
+	loader := &mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+		{ID: 2, Name: "Jane", Email: "jane@example.com"},
+	}}
+	cache := NewCacheManager[models.User](loader).WithTTL(time.Hour)
+	assert.NoError(t, cache.Refresh())
+
+	var buf bytes.Buffer
+	assert.NoError(t, cache.Snapshot(&buf))
+
+	restored := NewCacheManager[models.User](nil).WithTTL(time.Hour)
+	assert.NoError(t, restored.LoadSnapshot(&buf))
+
+	assert.ElementsMatch(t, cache.GetAll(), restored.GetAll())
+	assert.True(t, cache.lastFetch.Equal(restored.lastFetch), "the fetch time should survive the round trip")
+	_, meta, ok := restored.GetWithMeta(1)
+	assert.True(t, ok)
+	assert.True(t, meta.LoadedAt.Equal(cache.lastFetch))
+
+	assert.Error(t, restored.LoadSnapshot(bytes.NewBufferString("not json")))
+}
+
+func TestCacheManagerDrainAndClose(t *testing.T) {
+	loader := &mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John", Email: "john@example.com"},
+	}}
+	cache := NewCacheManager[models.User](loader)
+	assert.NoError(t, cache.Refresh())
+
+	var buf bytes.Buffer
+	assert.NoError(t, cache.DrainAndClose(&buf))
+
+	t.Run("refresh is stopped", func(t *testing.T) {
+		assert.True(t, errors.Is(cache.Refresh(), ErrCacheClosed))
+		assert.True(t, errors.Is(cache.RefreshIncremental("updated_at"), ErrCacheClosed))
+		user, err := cache.Get(1)
+		assert.NoError(t, err, "reads keep serving after close")
+		assert.Equal(t, "John", user.Name)
+	})
+
+	t.Run("snapshot restores on startup", func(t *testing.T) {
+		next := NewCacheManager[models.User](loader)
+		assert.NoError(t, next.LoadSnapshot(&buf))
+		user, err := next.Get(1)
+		assert.NoError(t, err)
+		assert.Equal(t, "John", user.Name)
+	})
+}
+
+func TestCacheManagerCloseWaitsForRefresh(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	cache := NewCacheManager[models.User](loaderFunc[models.User](func() ([]models.User, error) {
+		close(started)
+		<-release
+		return []models.User{{ID: 1, Name: "John"}}, nil
+	}))
+
+	refreshed := make(chan error, 1)
+	go func() { refreshed <- cache.Refresh() }()
+	<-started
+
+	closed := make(chan struct{})
+	go func() {
+		cache.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("Close returned while a refresh was loading")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	<-closed
+	assert.NoError(t, <-refreshed)
+	assert.True(t, cache.Has(1), "the refresh completed before Close returned")
+	assert.ErrorIs(t, cache.Refresh(), ErrCacheClosed)
+}
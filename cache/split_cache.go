@@ -0,0 +1,77 @@
+package cache
+
+import "fmt"
+
+// SplitCache serves an entity split into two projections cached with
+// independent TTLs: a hot part holding the fields that change often, e.g.
+// an order's status, and a cold part holding the mostly static ones. Get
+// joins both parts by ID with the user supplied merge function, so only the
+// hot part has to be reloaded often.
+type SplitCache[T any, H Identifiable, C Identifiable] struct {
+	hot   *CacheManager[H]
+	cold  *CacheManager[C]
+	merge func(hot H, cold C) T
+}
+
+// NewSplitCache creates a split cache over the hot and cold caches, which
+// keep their own loaders and TTLs. merge assembles the entity from the two
+// parts with the same ID.
+func NewSplitCache[T any, H Identifiable, C Identifiable](hot *CacheManager[H], cold *CacheManager[C], merge func(hot H, cold C) T) *SplitCache[T, H, C] {
+	return &SplitCache[T, H, C]{
+		hot:   hot,
+		cold:  cold,
+		merge: merge,
+	}
+}
+
+// Hot returns the cache holding the frequently changing part
+func (s *SplitCache[T, H, C]) Hot() *CacheManager[H] {
+	return s.hot
+}
+
+// Cold returns the cache holding the mostly static part
+func (s *SplitCache[T, H, C]) Cold() *CacheManager[C] {
+	return s.cold
+}
+
+// Get retrieves both parts of an entity and merges them. It fails if either
+// part cannot be served, with the error of the failing part.
+func (s *SplitCache[T, H, C]) Get(id uint) (T, error) {
+	var zero T
+	hot, err := s.hot.Get(id)
+	if err != nil {
+		return zero, fmt.Errorf("hot part: %w", err)
+	}
+	cold, err := s.cold.Get(id)
+	if err != nil {
+		return zero, fmt.Errorf("cold part: %w", err)
+	}
+	return s.merge(hot, cold), nil
+}
+
+// GetAll returns the merged entities whose parts are both cached
+func (s *SplitCache[T, H, C]) GetAll() []T {
+	colds := make(map[uint]C)
+	for id, cold := range s.cold.All() {
+		colds[id] = cold
+	}
+	result := make([]T, 0, len(colds))
+	for id, hot := range s.hot.All() {
+		if cold, ok := colds[id]; ok {
+			result = append(result, s.merge(hot, cold))
+		}
+	}
+	return result
+}
+
+// Refresh reloads both parts. Refresh the parts individually through Hot
+// and Cold to reload them on their own schedules.
+func (s *SplitCache[T, H, C]) Refresh() error {
+	if err := s.hot.Refresh(); err != nil {
+		return fmt.Errorf("failed to refresh hot part: %w", err)
+	}
+	if err := s.cold.Refresh(); err != nil {
+		return fmt.Errorf("failed to refresh cold part: %w", err)
+	}
+	return nil
+}
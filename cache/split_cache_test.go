@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// orderStatus is the hot part of an order
+type orderStatus struct {
+	ID     uint
+	Status string
+}
+
+func (o orderStatus) GetID() uint { return o.ID }
+
+// orderInfo is the cold part of an order
+type orderInfo struct {
+	ID       uint
+	Customer string
+}
+
+func (o orderInfo) GetID() uint { return o.ID }
+
+type fullOrder struct {
+	ID       uint
+	Status   string
+	Customer string
+}
+
+// sliceLoader loads a fixed slice and counts its loads
+type sliceLoader[T any] struct {
+	items []T
+	err   error
+	loads int
+}
+
+func (l *sliceLoader[T]) Load() ([]T, error) {
+	l.loads++
+	return l.items, l.err
+}
+
+func TestSplitCache(t *testing.T) {
+	hotLoader := &sliceLoader[orderStatus]{items: []orderStatus{{ID: 1, Status: "pending"}, {ID: 2, Status: "paid"}}}
+	coldLoader := &sliceLoader[orderInfo]{items: []orderInfo{{ID: 1, Customer: "John"}, {ID: 2, Customer: "Jane"}}}
+
+	split := NewSplitCache(
+		NewCacheManager[orderStatus](hotLoader).WithTTL(time.Second),
+		NewCacheManager[orderInfo](coldLoader).WithTTL(time.Hour),
+		func(hot orderStatus, cold orderInfo) fullOrder {
+			return fullOrder{ID: hot.ID, Status: hot.Status, Customer: cold.Customer}
+		},
+	)
+	assert.NoError(t, split.Refresh())
+
+	order, err := split.Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, fullOrder{ID: 1, Status: "pending", Customer: "John"}, order)
+	assert.ElementsMatch(t, []fullOrder{
+		{ID: 1, Status: "pending", Customer: "John"},
+		{ID: 2, Status: "paid", Customer: "Jane"},
+	}, split.GetAll())
+
+	t.Run("hot part refreshes independently", func(t *testing.T) {
+		hotLoader.items = []orderStatus{{ID: 1, Status: "shipped"}, {ID: 2, Status: "paid"}}
+		coldLoader.err = errors.New("cold source must not be hit")
+
+		assert.NoError(t, split.Hot().Refresh())
+		assert.Equal(t, 1, coldLoader.loads)
+		assert.Equal(t, 2, hotLoader.loads)
+
+		order, err := split.Get(1)
+		assert.NoError(t, err)
+		assert.Equal(t, fullOrder{ID: 1, Status: "shipped", Customer: "John"}, order)
+	})
+
+	t.Run("a missing part fails the read", func(t *testing.T) {
+		hotLoader.items = append(hotLoader.items, orderStatus{ID: 3, Status: "new"})
+		assert.NoError(t, split.Hot().Refresh())
+
+		_, err := split.Get(3)
+		assert.EqualError(t, err, "cold part: item with ID 3 not found")
+		assert.Len(t, split.GetAll(), 2)
+	})
+}
@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheStats is a snapshot of the access counters of a CacheManager
+type CacheStats struct {
+	Name      string // see WithName
+	Hits      uint64 // Get calls that returned an item
+	Misses    uint64 // Get calls that returned an error
+	Evictions uint64 // entries dropped to stay within a size bound
+}
+
+// cacheStats holds the live counters behind CacheStats
+type cacheStats struct {
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+func (s *cacheStats) record(hit bool) {
+	if hit {
+		s.hits.Add(1)
+	} else {
+		s.misses.Add(1)
+	}
+}
+
+func (s *cacheStats) reset() {
+	s.hits.Store(0)
+	s.misses.Store(0)
+	s.evictions.Store(0)
+}
+
+// accesses returns the total number of recorded Get calls
+func (s *cacheStats) accesses() uint64 {
+	return s.hits.Load() + s.misses.Load()
+}
+
+// Stats returns the current access counters
+func (cm *CacheManager[T]) Stats() CacheStats {
+	return CacheStats{
+		Name:      cm.name,
+		Hits:      cm.stats.hits.Load(),
+		Misses:    cm.stats.misses.Load(),
+		Evictions: cm.stats.evictions.Load(),
+	}
+}
+
+// adaptiveRefresh tracks the refresh interval of a cache in adaptive mode
+type adaptiveRefresh struct {
+	mu           sync.Mutex
+	min, max     time.Duration
+	interval     time.Duration
+	lastAccesses uint64
+}
+
+// reset restarts the interval at max with no accesses seen
+func (a *adaptiveRefresh) reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.interval = a.max
+	a.lastAccesses = 0
+}
+
+// WithAdaptiveRefresh makes the refresh interval follow the access rate:
+// NextRefreshInterval halves the interval after a period with accesses and
+// doubles it after an idle period, always within [min, max]. The interval
+// starts at max so a cache only gets refreshed often once it is used.
+func (cm *CacheManager[T]) WithAdaptiveRefresh(minInterval, maxInterval time.Duration) *CacheManager[T] {
+	if minInterval > maxInterval {
+		minInterval, maxInterval = maxInterval, minInterval
+	}
+	cm.adaptive = &adaptiveRefresh{
+		min:          minInterval,
+		max:          maxInterval,
+		interval:     maxInterval,
+		lastAccesses: cm.stats.accesses(),
+	}
+	return cm
+}
+
+// NextRefreshInterval returns how long to wait before the next refresh. In
+// adaptive mode every call closes the current period and adjusts the
+// interval based on the Get calls seen since the previous call; otherwise
+// it returns the TTL.
+func (cm *CacheManager[T]) NextRefreshInterval() time.Duration {
+	a := cm.adaptive
+	if a == nil {
+		return cm.ttl
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	accesses := cm.stats.accesses()
+	if accesses > a.lastAccesses {
+		a.interval /= 2
+	} else {
+		a.interval *= 2
+	}
+	a.lastAccesses = accesses
+	a.interval = min(max(a.interval, a.min), a.max)
+	return a.interval
+}
+
+// expvarMu serializes PublishExpvar so the name check and Publish are atomic
+var expvarMu sync.Mutex
+
+// expvarStats is the JSON published by PublishExpvar
+type expvarStats struct {
+	Name       string  `json:"name,omitempty"`
+	Size       int     `json:"size"`
+	Hits       uint64  `json:"hits"`
+	Misses     uint64  `json:"misses"`
+	AgeSeconds float64 `json:"age_seconds"` // 0 if never refreshed
+	LastError  string  `json:"last_error,omitempty"`
+}
+
+// PublishExpvar registers the cache's size, hits, misses, age and last load
+// error as an expvar.Var under name, served as JSON at /debug/vars. Each
+// cache needs its own name; publishing an already registered name returns
+// an error instead of panicking like expvar.Publish.
+func (cm *CacheManager[T]) PublishExpvar(name string) error {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("expvar %q is already published", name)
+	}
+	expvar.Publish(name, expvar.Func(func() any {
+		return cm.expvarStats()
+	}))
+	return nil
+}
+
+func (cm *CacheManager[T]) expvarStats() expvarStats {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	stats := cm.Stats()
+	out := expvarStats{
+		Name:   cm.name,
+		Size:   len(cm.data),
+		Hits:   stats.Hits,
+		Misses: stats.Misses,
+	}
+	if !cm.lastFetch.IsZero() {
+		out.AgeSeconds = time.Since(cm.lastFetch).Seconds()
+	}
+	if cm.lastErr != nil {
+		out.LastError = cm.lastErr.Error()
+	}
+	return out
+}
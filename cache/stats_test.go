@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheManagerStats(t *testing.T) {
+	cache := NewCacheManager[models.User](&mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John"},
+	}})
+
+	_, _ = cache.Get(1) // not initialized
+	assert.NoError(t, cache.Refresh())
+	_, _ = cache.Get(1)
+	_, _ = cache.Get(1)
+	_, _ = cache.Get(2)
+
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 2}, cache.Stats())
+}
+
+func TestCacheManagerAdaptiveRefresh(t *testing.T) {
+	cache := NewCacheManager[models.User](&mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John"},
+	}}).WithTTL(time.Minute)
+	assert.NoError(t, cache.Refresh())
+
+	assert.Equal(t, time.Minute, cache.NextRefreshInterval(), "without adaptive mode the TTL is used")
+
+	cache.WithAdaptiveRefresh(time.Second, 16*time.Second)
+
+	t.Run("moves toward min under load", func(t *testing.T) {
+		want := []time.Duration{8 * time.Second, 4 * time.Second, 2 * time.Second, time.Second, time.Second}
+		for _, interval := range want {
+			_, _ = cache.Get(1)
+			assert.Equal(t, interval, cache.NextRefreshInterval())
+		}
+	})
+
+	t.Run("moves toward max when idle", func(t *testing.T) {
+		want := []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 16 * time.Second}
+		for _, interval := range want {
+			assert.Equal(t, interval, cache.NextRefreshInterval())
+		}
+	})
+}
+
+func TestCacheManagerPublishExpvar(t *testing.T) {
+	loader := &mockUserLoader{users: []models.User{
+		{ID: 1, Name: "John"},
+		{ID: 2, Name: "Jane"},
+	}}
+	users := NewCacheManager[models.User](loader)
+	assert.NoError(t, users.Refresh())
+	_, _ = users.Get(1)
+	_, _ = users.Get(3)
+
+	// expvar names are process wide, keep them unique across -count runs
+	suffix := time.Now().UnixNano()
+	usersName := fmt.Sprintf("test_users_cache_%d", suffix)
+	otherName := fmt.Sprintf("test_other_cache_%d", suffix)
+
+	other := NewCacheManager[models.User](&mockUserLoader{})
+	assert.NoError(t, users.PublishExpvar(usersName))
+	assert.NoError(t, other.PublishExpvar(otherName))
+	assert.Error(t, other.PublishExpvar(usersName), "names must not collide")
+
+	read := func(name string) expvarStats {
+		var stats expvarStats
+		assert.NoError(t, json.Unmarshal([]byte(expvar.Get(name).String()), &stats))
+		return stats
+	}
+
+	stats := read(usersName)
+	assert.Equal(t, 2, stats.Size)
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Greater(t, stats.AgeSeconds, float64(0))
+	assert.Empty(t, stats.LastError)
+
+	loader.err = errors.New("database unavailable")
+	assert.Error(t, users.Refresh())
+	assert.Equal(t, "database unavailable", read(usersName).LastError)
+
+	assert.Equal(t, expvarStats{}, read(otherName))
+}
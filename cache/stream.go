@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// StreamOut streams the cached items in pages of up to pageSize items,
+// sorted by ID, e.g. to build a replica on another node. See StreamOutFrom.
+func (cm *CacheManager[T]) StreamOut(ctx context.Context, pageSize int) (<-chan []T, <-chan error) {
+	return cm.StreamOutFrom(ctx, 0, pageSize)
+}
+
+// StreamOutFrom streams the cached items with an ID greater than after, so
+// a consumer can resume an interrupted transfer from the last ID it
+// received. The IDs are captured when streaming starts and each page takes
+// the read lock only while it is copied, so writers are never blocked for
+// the whole transfer. The result is eventually consistent: items added
+// after the start are not streamed, items deleted meanwhile are skipped and
+// updated items are streamed as of their page.
+//
+// The pages channel is closed when the stream ends; the error channel then
+// yields at most one error, e.g. ctx.Err() if ctx was canceled.
+func (cm *CacheManager[T]) StreamOutFrom(ctx context.Context, after uint, pageSize int) (<-chan []T, <-chan error) {
+	pages := make(chan []T)
+	errs := make(chan error, 1)
+	if pageSize <= 0 {
+		close(pages)
+		errs <- fmt.Errorf("invalid page size %d", pageSize)
+		close(errs)
+		return pages, errs
+	}
+
+	cm.mu.RLock()
+	initialized := !cm.notInitialized()
+	ids := make([]uint, 0, len(cm.data))
+	for id := range cm.data {
+		if id > after {
+			ids = append(ids, id)
+		}
+	}
+	cm.mu.RUnlock()
+	slices.Sort(ids)
+
+	go func() {
+		defer close(errs)
+		defer close(pages)
+		if !initialized {
+			errs <- ErrCacheNotInitialized
+			return
+		}
+
+		for len(ids) > 0 {
+			if err := ctx.Err(); err != nil {
+				errs <- err
+				return
+			}
+			n := min(pageSize, len(ids))
+			page := make([]T, 0, n)
+			cm.mu.RLock()
+			for _, id := range ids[:n] {
+				if item, exists := cm.data[id]; exists {
+					page = append(page, item)
+				}
+			}
+			cm.mu.RUnlock()
+			ids = ids[n:]
+
+			if len(page) == 0 {
+				continue
+			}
+			select {
+			case pages <- page:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return pages, errs
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheManagerStreamOut(t *testing.T) {
+	source := NewSyntheticCacheManager(2500, syntheticUser)
+
+	t.Run("reconstructs the cache page by page", func(t *testing.T) {
+		pages, errs := source.StreamOut(context.Background(), 1000)
+		replica := NewCacheManager[models.User](nil)
+		var sizes []int
+		var last uint
+		for page := range pages {
+			sizes = append(sizes, len(page))
+			for _, u := range page {
+				assert.Greater(t, u.ID, last, "pages are sorted by ID")
+				last = u.ID
+			}
+			replica.SetAll(page)
+		}
+		assert.NoError(t, <-errs)
+		assert.Equal(t, []int{1000, 1000, 500}, sizes)
+		assert.ElementsMatch(t, source.GetAll(), replica.GetAll())
+	})
+
+	t.Run("resumes after an ID and skips deleted items", func(t *testing.T) {
+		pages, errs := source.StreamOutFrom(context.Background(), 2490, 4)
+		source.Delete(2495)
+		var got []uint
+		for page := range pages {
+			got = append(got, ids(page)...)
+		}
+		assert.NoError(t, <-errs)
+		assert.Equal(t, []uint{2491, 2492, 2493, 2494, 2496, 2497, 2498, 2499, 2500}, got)
+	})
+
+	t.Run("stops when the context is canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		pages, errs := source.StreamOut(ctx, 10)
+		<-pages
+		cancel()
+		for range pages {
+		}
+		assert.True(t, errors.Is(<-errs, context.Canceled))
+	})
+
+	t.Run("rejects invalid page sizes", func(t *testing.T) {
+		pages, errs := source.StreamOut(context.Background(), 0)
+		_, open := <-pages
+		assert.False(t, open)
+		assert.Error(t, <-errs)
+	})
+
+	t.Run("requires an initialized cache", func(t *testing.T) {
+		cold := NewCacheManager[models.User](&mockUserLoader{})
+		pages, errs := cold.StreamOut(context.Background(), 10)
+		for range pages {
+		}
+		assert.ErrorIs(t, <-errs, ErrCacheNotInitialized)
+	})
+}
@@ -0,0 +1,23 @@
+package cache
+
+// SliceLoader is a DataLoader that returns a fixed slice of items, e.g. for
+// tests and benchmarks
+type SliceLoader[T any] []T
+
+// Load returns the items of the slice
+func (l SliceLoader[T]) Load() ([]T, error) {
+	return l, nil
+}
+
+// NewSyntheticCacheManager creates a permanent cache holding n items built by
+// gen for the IDs 1 to n, for benchmarks of the cache and of code built on
+// it. Its loader returns the same items, so Refresh reloads the full dataset.
+func NewSyntheticCacheManager[T Identifiable](n int, gen func(id uint) T) *CacheManager[T] {
+	items := make(SliceLoader[T], n)
+	for i := range items {
+		items[i] = gen(uint(i + 1))
+	}
+	cm := NewCacheManager[T](items)
+	cm.Apply(items)
+	return cm
+}
@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// Refreshable is a cache that RefreshTogether can refresh. It is
+// implemented by CacheManager and RelatedCacheManager.
+type Refreshable interface {
+	Refresh() error
+
+	// stageRefresh loads fresh data without holding the lock. swap installs
+	// it and must be called with the write lock of swapLock held; it
+	// returns work to run once the lock is released.
+	stageRefresh() (swap func() (after func()), err error)
+	swapLock() *sync.RWMutex
+	// checkOpen returns ErrCacheClosed once the cache is closed
+	checkOpen() error
+}
+
+// togetherMu serializes RefreshTogether calls. swapBarrier is held for
+// writing while RefreshTogether swaps and for reading by ReadTogether.
+var (
+	togetherMu  sync.Mutex
+	swapBarrier sync.RWMutex
+)
+
+// RefreshTogether refreshes several caches as one, e.g. users and the
+// orders referencing them. All loads run first, without holding any lock;
+// if one fails nothing is swapped and its error is returned. The new data
+// is then swapped in while the write locks of all caches are held, taken
+// in address order like Merge. A cache passed more than once is refreshed
+// once. Each cache is still read under its own lock, so a reader that reads
+// several caches in a row can straddle the swap; run such reads in
+// ReadTogether to see all caches either before or after it.
+func RefreshTogether(caches ...Refreshable) error {
+	togetherMu.Lock()
+	defer togetherMu.Unlock()
+
+	caches = uniqueRefreshables(caches)
+	swaps := make([]func() func(), len(caches))
+	for i, c := range caches {
+		swap, err := c.stageRefresh()
+		if err != nil {
+			return fmt.Errorf("failed to load cache %d of %d: %w", i+1, len(caches), err)
+		}
+		swaps[i] = swap
+	}
+
+	afters, err := swapAll(caches, swaps)
+	if err != nil {
+		return err
+	}
+	for _, after := range afters {
+		after()
+	}
+	return nil
+}
+
+// ReadTogether runs fn so that it observes every RefreshTogether swap
+// either wholly before or wholly after, e.g. when reading users and then
+// their orders. fn must not call RefreshTogether or ReadTogether.
+func ReadTogether(fn func()) {
+	swapBarrier.RLock()
+	defer swapBarrier.RUnlock()
+	fn()
+}
+
+// uniqueRefreshables drops caches that share a lock with an earlier one
+func uniqueRefreshables(caches []Refreshable) []Refreshable {
+	seen := make(map[*sync.RWMutex]struct{}, len(caches))
+	unique := make([]Refreshable, 0, len(caches))
+	for _, c := range caches {
+		if _, dup := seen[c.swapLock()]; dup {
+			continue
+		}
+		seen[c.swapLock()] = struct{}{}
+		unique = append(unique, c)
+	}
+	return unique
+}
+
+// swapAll runs the swaps under the swap barrier and the write locks of all
+// caches, unless one of them was closed since it was staged. The unlocks
+// are deferred since swaps run user callbacks.
+func swapAll(caches []Refreshable, swaps []func() func()) ([]func(), error) {
+	swapBarrier.Lock()
+	defer swapBarrier.Unlock()
+
+	locks := make([]*sync.RWMutex, len(caches))
+	for i, c := range caches {
+		locks[i] = c.swapLock()
+	}
+	sort.Slice(locks, func(i, j int) bool {
+		return uintptr(unsafe.Pointer(locks[i])) < uintptr(unsafe.Pointer(locks[j]))
+	})
+	for _, lock := range locks {
+		lock.Lock()
+		defer lock.Unlock()
+	}
+
+	for i, c := range caches {
+		if err := c.checkOpen(); err != nil {
+			return nil, fmt.Errorf("failed to swap cache %d of %d: %w", i+1, len(caches), err)
+		}
+	}
+	afters := make([]func(), len(swaps))
+	for i, swap := range swaps {
+		afters[i] = swap()
+	}
+	return afters, nil
+}
+
+func (cm *CacheManager[T]) swapLock() *sync.RWMutex {
+	return &cm.mu
+}
+
+func (cm *CacheManager[T]) checkOpen() error {
+	if cm.closed.Load() {
+		return cm.named(ErrCacheClosed)
+	}
+	return nil
+}
+
+func (cm *CacheManager[T]) stageRefresh() (func() func(), error) {
+	if cm.closed.Load() {
+		return nil, ErrCacheClosed
+	}
+	cm.mu.RLock()
+	loader := cm.loader
+	cm.mu.RUnlock()
+	if loader == nil {
+		return nil, cm.named(ErrNoLoader)
+	}
+
+	items, err := cm.load(loader.Load)
+	if err != nil {
+		cm.mu.Lock()
+		cm.recordError(err)
+		cm.mu.Unlock()
+		return nil, cm.named(err)
+	}
+	return func() func() {
+		result := cm.swapData(items, time.Now(), false)
+		return func() {
+			cm.notifyExpire()
+			cm.fireRefresh(result)
+		}
+	}, nil
+}
+
+func (rcm *RelatedCacheManager[T]) swapLock() *sync.RWMutex {
+	return &rcm.mu
+}
+
+// checkOpen never fails: a RelatedCacheManager cannot be closed
+func (rcm *RelatedCacheManager[T]) checkOpen() error {
+	return nil
+}
+
+func (rcm *RelatedCacheManager[T]) stageRefresh() (func() func(), error) {
+	rcm.mu.RLock()
+	loader, enrich, skipZeroFK := rcm.loader, rcm.enrich, rcm.skipZeroFK
+	rcm.mu.RUnlock()
+	if loader == nil {
+		return nil, rcm.named(ErrNoLoader)
+	}
+
+	items, err := rcm.fetch(loader.Load, enrich)
+	if err != nil {
+		return nil, rcm.named(err)
+	}
+	d := rcm.index(items, skipZeroFK)
+	return func() func() {
+		rcm.install(d)
+		return func() {}
+	}, nil
+}
@@ -0,0 +1,228 @@
+package cache
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// generationLoaders serve users 1..gen and, for each of them, an order
+type generationLoaders struct {
+	gen atomic.Uint32
+}
+
+func (g *generationLoaders) users() DataLoader[models.User] {
+	return loaderFunc[models.User](func() ([]models.User, error) {
+		users := make([]models.User, g.gen.Load())
+		for i := range users {
+			users[i] = models.User{ID: uint(i + 1)}
+		}
+		return users, nil
+	})
+}
+
+func (g *generationLoaders) orders() DataLoader[models.Order] {
+	return loaderFunc[models.Order](func() ([]models.Order, error) {
+		orders := make([]models.Order, g.gen.Load())
+		for i := range orders {
+			orders[i] = models.Order{ID: uint(i + 1), UserID: uint(i + 1)}
+		}
+		return orders, nil
+	})
+}
+
+type loaderFunc[T any] func() ([]T, error)
+
+func (f loaderFunc[T]) Load() ([]T, error) { return f() }
+
+func TestRefreshTogether(t *testing.T) {
+	g := &generationLoaders{}
+	g.gen.Store(1)
+	users := NewCacheManager[models.User](g.users()).WithTTL(time.Hour)
+	orders := NewRelatedCacheManager[models.Order](g.orders(), time.Hour)
+	assert.NoError(t, RefreshTogether(users, orders))
+
+	var stop atomic.Bool
+	var missing atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !stop.Load() {
+				for _, order := range orders.GetAll() {
+					if !users.Has(order.UserID) {
+						missing.Add(1)
+					}
+				}
+			}
+		}()
+	}
+
+	for gen := uint32(2); gen <= 200; gen++ {
+		g.gen.Store(gen)
+		assert.NoError(t, RefreshTogether(orders, users))
+	}
+	stop.Store(true)
+	wg.Wait()
+
+	assert.Zero(t, missing.Load(), "no reader saw an order whose user was not loaded")
+	assert.Len(t, users.GetAll(), 200)
+	assert.Len(t, orders.GetAll(), 200)
+}
+
+func TestRefreshTogetherFailure(t *testing.T) {
+	g := &generationLoaders{}
+	g.gen.Store(1)
+	users := NewCacheManager[models.User](g.users())
+	loadErr := errors.New("orders db down")
+	orders := NewRelatedCacheManager[models.Order](&mockOrderLoader{err: loadErr}, time.Hour)
+
+	err := RefreshTogether(users, orders)
+	assert.ErrorIs(t, err, loadErr)
+	assert.Empty(t, users.Keys(), "nothing is swapped when a load fails")
+	assert.True(t, users.lastFetch.IsZero())
+}
+
+// shrinkingLoaders alternate between users 1 and 2 with an order each, and
+// user 1 alone, so every other refresh deletes user 2 and its order
+type shrinkingLoaders struct {
+	gen atomic.Uint32
+}
+
+func (g *shrinkingLoaders) n() uint { return uint(1 + g.gen.Load()%2) }
+
+func (g *shrinkingLoaders) users() DataLoader[models.User] {
+	return loaderFunc[models.User](func() ([]models.User, error) {
+		users := make([]models.User, g.n())
+		for i := range users {
+			users[i] = models.User{ID: uint(i + 1)}
+		}
+		return users, nil
+	})
+}
+
+func (g *shrinkingLoaders) orders() DataLoader[models.Order] {
+	return loaderFunc[models.Order](func() ([]models.Order, error) {
+		orders := make([]models.Order, g.n())
+		for i := range orders {
+			orders[i] = models.Order{ID: uint(i + 1), UserID: uint(i + 1)}
+		}
+		return orders, nil
+	})
+}
+
+func TestReadTogether(t *testing.T) {
+	g := &shrinkingLoaders{}
+	users := NewCacheManager[models.User](g.users()).WithTTL(time.Hour)
+	orders := NewRelatedCacheManager[models.Order](g.orders(), time.Hour)
+	assert.NoError(t, RefreshTogether(users, orders))
+
+	var stop atomic.Bool
+	var missing atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !stop.Load() {
+				ReadTogether(func() {
+					known := make(map[uint]bool)
+					for _, id := range users.Keys() {
+						known[id] = true
+					}
+					runtime.Gosched() // widen the window between the reads
+					for _, order := range orders.GetAll() {
+						if !known[order.UserID] {
+							missing.Add(1)
+						}
+					}
+				})
+			}
+		}()
+	}
+
+	for gen := uint32(1); gen <= 2000; gen++ {
+		g.gen.Store(gen)
+		assert.NoError(t, RefreshTogether(users, orders))
+		runtime.Gosched()
+	}
+	stop.Store(true)
+	wg.Wait()
+
+	assert.Zero(t, missing.Load(), "no reader saw users from before a swap with orders from after it")
+}
+
+func TestRefreshTogetherLocking(t *testing.T) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a := NewSyntheticCacheManager(10, syntheticUser)
+		b := NewSyntheticCacheManager(10, syntheticUser)
+		assert.NoError(t, RefreshTogether(a, a, b), "a cache passed twice is refreshed once")
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				a.Merge(b, MergeOverwrite)
+				b.Merge(a, MergeOverwrite)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				assert.NoError(t, RefreshTogether(b, a))
+			}
+		}()
+		wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RefreshTogether deadlocked")
+	}
+}
+
+func TestRefreshTogetherWithoutLoader(t *testing.T) {
+	users := NewSyntheticCacheManager(1, syntheticUser)
+	orders := NewRelatedCacheManager[models.Order](nil, time.Hour)
+	assert.ErrorIs(t, RefreshTogether(users, orders), ErrNoLoader)
+	assert.ErrorIs(t, orders.Refresh(), ErrNoLoader)
+}
+
+func TestRefreshTogetherClosedDuringLoad(t *testing.T) {
+	var users *CacheManager[models.User]
+	users = NewCacheManager[models.User](loaderFunc[models.User](func() ([]models.User, error) {
+		users.Close()
+		return []models.User{{ID: 1}}, nil
+	}))
+	orders := NewRelatedCacheManager[models.Order](&mockOrderLoader{}, time.Hour)
+
+	assert.ErrorIs(t, RefreshTogether(users, orders), ErrCacheClosed)
+	assert.Empty(t, users.Keys(), "a closed cache is not swapped")
+}
+
+func TestRefreshTogetherSetLoader(t *testing.T) {
+	users := NewSyntheticCacheManager(3, syntheticUser)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			users.SetLoader(SliceLoader[models.User]{syntheticUser(1)})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, RefreshTogether(users))
+	}
+	wg.Wait()
+}
@@ -144,7 +144,7 @@ func UseMongoExample(ctx context.Context, coll *mongo.Collection) {
 		})
 
 	// Create cache for high-value orders
-	orderCache := cache.NewRelatedCacheManager[models.Order](orderLoader, 1*time.Minute)
+	orderCache := cache.NewRelatedCacheManager[models.Order](orderLoader, 0).WithTTL(1 * time.Minute)
 
 	// Initialize cache
 	if err := orderCache.Refresh(); err != nil {
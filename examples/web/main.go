@@ -70,8 +70,11 @@ func NewServer() (*Server, error) {
 		WithDebug(true)
 
 	// Initialize caches with TTL
-	userCache := cache.NewCacheManager[models.UserV2](userLoader).WithTTL(5 * time.Minute)
-	orderCache := cache.NewRelatedCacheManager[models.Order](orderLoader, 1*time.Minute)
+	// Sort by ID so that JSON responses are reproducible
+	userCache := cache.NewCacheManager[models.UserV2](userLoader).WithTTL(5 * time.Minute).
+		WithDeterministicOrder(func(a, b models.UserV2) bool { return a.ID < b.ID })
+	orderCache := cache.NewRelatedCacheManager[models.Order](orderLoader, 0).WithTTL(1 * time.Minute).
+		WithDeterministicOrder(func(a, b models.Order) bool { return a.ID < b.ID })
 
 	// Refresh caches
 	if err := userCache.Refresh(); err != nil {
@@ -136,13 +139,7 @@ func (s *Server) handleSearchUsers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	nameCondition := cache.StringFieldCondition[models.UserV2]{
-		FieldExtractor: func(u models.UserV2) string { return u.Name },
-		Value:          name,
-		Operation:      "contains",
-	}
-
-	users := s.userCache.Query(nameCondition)
+	users := s.userCache.QuerySearch(func(u models.UserV2) string { return u.Name }, name)
 	json.NewEncoder(w).Encode(users)
 }
 
@@ -180,6 +177,7 @@ func main() {
 	http.HandleFunc("/api/user/orders", server.handleGetUserOrders)
 	http.HandleFunc("/api/users/search", server.handleSearchUsers)
 	http.HandleFunc("/api/orders/high-value", server.handleHighValueOrders)
+	http.Handle("/debug/cache/users/", http.StripPrefix("/debug/cache/users", cache.DebugHandler(server.userCache, false)))
 
 	// Start server
 	log.Println("Server starting on :8080")
@@ -1,6 +1,6 @@
 module github.com/costa92/multicache
 
-go 1.22
+go 1.23
 
 require (
 	github.com/alecthomas/assert v1.0.0
@@ -8,6 +8,7 @@ require (
 	go.mongodb.org/mongo-driver v1.14.0
 	gorm.io/driver/sqlite v1.5.5
 	gorm.io/gorm v1.25.7
+	gorm.io/plugin/dbresolver v1.5.2
 )
 
 require (
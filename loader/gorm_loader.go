@@ -1,9 +1,18 @@
 package loader
 
 import (
+	"context"
 	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"gorm.io/plugin/dbresolver"
 )
 
 // GormLoader implements DataLoader interface for GORM
@@ -17,6 +26,23 @@ type GormLoader[T any] struct {
 	preloadJoins   map[string][]interface{}
 	preloadQueries map[string][]interface{}
 	debug          bool
+	replica        bool
+	jsonConditions []jsonCondition
+	searches       []fullTextSearch
+	exists         []existsCondition
+	condPreloads   []conditionalPreload[T]
+	comment        string
+	afterLoad      func([]T) ([]T, error)
+	query          *gorm.DB // prebuilt query, see NewGormLoaderFromQuery
+	orders         []string
+	selects        []string
+	limit          int
+	offset         int
+}
+
+type conditionalPreload[T any] struct {
+	relation  string
+	predicate func(T) bool
 }
 
 type joinModel struct {
@@ -25,6 +51,35 @@ type joinModel struct {
 	referenceKey string
 }
 
+type fullTextSearch struct {
+	columns []string
+	term    string
+}
+
+type existsCondition struct {
+	sub        SubQuery
+	foreignKey string
+}
+
+// SubQuery is a loader usable as a correlated subquery of another loader.
+// It is implemented by *GormLoader for any model type.
+type SubQuery interface {
+	correlatedQuery(parentTable, parentKey, foreignKey string) (*gorm.DB, error)
+}
+
+type jsonCondition struct {
+	column string
+	path   string
+	op     string
+	value  interface{}
+}
+
+var (
+	columnPattern   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+	jsonPathPattern = regexp.MustCompile(`^[A-Za-z0-9_]+(\.[A-Za-z0-9_]+)*$`)
+	jsonOperators   = map[string]bool{"=": true, "!=": true, "<>": true, ">": true, ">=": true, "<": true, "<=": true, "LIKE": true}
+)
+
 // NewGormLoader creates a new GORM data loader
 func NewGormLoader[T any](db *gorm.DB, model T) *GormLoader[T] {
 	return &GormLoader[T]{
@@ -35,6 +90,17 @@ func NewGormLoader[T any](db *gorm.DB, model T) *GormLoader[T] {
 	}
 }
 
+// NewGormLoaderFromQuery creates a loader that runs a query built
+// elsewhere, as an escape hatch for queries the builder methods cannot
+// express. The query is used as is: builder methods that shape the query,
+// such as WithCondition or WithPreload, have no effect, while WithDebug,
+// WithConditionalPreload and WithAfterLoad still apply.
+func NewGormLoaderFromQuery[T any](query *gorm.DB) *GormLoader[T] {
+	l := NewGormLoader(query, *new(T))
+	l.query = query
+	return l
+}
+
 // WithCondition adds a query condition
 func (l *GormLoader[T]) WithCondition(query interface{}, args ...interface{}) *GormLoader[T] {
 	l.condition = append([]interface{}{query}, args...)
@@ -80,11 +146,193 @@ func (l *GormLoader[T]) WithDebug(debug bool) *GormLoader[T] {
 	return l
 }
 
+// WithReplica routes Load through the read replica pool. It relies on the
+// gorm.io/plugin/dbresolver plugin being registered on the *gorm.DB; without
+// the plugin the clause has no effect and the query runs on the default pool.
+func (l *GormLoader[T]) WithReplica() *GormLoader[T] {
+	l.replica = true
+	return l
+}
+
+// WithJSONCondition filters on a value inside a JSON column, e.g.
+// WithJSONCondition("metadata", "tier", "=", "gold"). Nested paths are dot
+// separated ("address.city"). Only PostgreSQL and MySQL are supported; on
+// other dialects, including SQLite, Load returns an error.
+func (l *GormLoader[T]) WithJSONCondition(column, path string, op string, value interface{}) *GormLoader[T] {
+	l.jsonConditions = append(l.jsonConditions, jsonCondition{column: column, path: path, op: op, value: value})
+	return l
+}
+
+// WithFullTextSearch filters rows whose columns match the search term.
+// PostgreSQL uses to_tsvector/plainto_tsquery and MySQL uses MATCH ... AGAINST
+// in natural language mode, which needs a FULLTEXT index on the columns.
+// Other dialects fall back to requiring every word of the term in at least
+// one of the columns via LIKE, without relevance ranking.
+func (l *GormLoader[T]) WithFullTextSearch(columns []string, term string) *GormLoader[T] {
+	l.searches = append(l.searches, fullTextSearch{columns: columns, term: term})
+	return l
+}
+
+// WithExists keeps only the rows for which the sub loader finds at least one
+// related row, e.g. users with orders via
+// WithExists(NewGormLoader(db, models.Order{}), "user_id"). The subquery
+// keeps the sub loader's conditions and is correlated by matching its
+// foreignKey column to this model's primary key. Unlike a join it never
+// duplicates parent rows.
+func (l *GormLoader[T]) WithExists(sub SubQuery, foreignKey string) *GormLoader[T] {
+	l.exists = append(l.exists, existsCondition{sub: sub, foreignKey: foreignKey})
+	return l
+}
+
+// WithConditionalPreload loads a has-one or has-many relation only for the
+// parents matching predicate, e.g. orders for active users only. After the
+// parents are loaded a single batched query fetches the children of the
+// selected parents; the relation of the other parents stays empty.
+func (l *GormLoader[T]) WithConditionalPreload(relation string, predicate func(T) bool) *GormLoader[T] {
+	l.condPreloads = append(l.condPreloads, conditionalPreload[T]{relation: relation, predicate: predicate})
+	return l
+}
+
+// WithQueryComment prefixes the loader's SELECT with a SQL comment, e.g.
+// "/* cache:user_cache */", so its queries can be identified in slow query
+// logs and pg_stat_statements. Comment delimiters and control characters
+// are stripped from the comment. Queries GORM issues for preloads are not
+// tagged.
+func (l *GormLoader[T]) WithQueryComment(comment string) *GormLoader[T] {
+	l.comment = sanitizeComment(comment)
+	return l
+}
+
+// WithSelect loads only the given columns, e.g. for a lightweight
+// projection cache of ids and names; the other fields stay zero. Columns
+// may be qualified with their table, e.g. "user_v2.id", which is needed
+// when joined tables share column names. Preloads need the keys they join
+// on, so keep the primary key and foreign keys in the selection.
+func (l *GormLoader[T]) WithSelect(columns ...string) *GormLoader[T] {
+	l.selects = append(l.selects, columns...)
+	return l
+}
+
+// WithOrder sorts the loaded rows, e.g. "created_at DESC". Multiple calls
+// chain in order.
+func (l *GormLoader[T]) WithOrder(clause string) *GormLoader[T] {
+	l.orders = append(l.orders, clause)
+	return l
+}
+
+// WithLimit caps the number of rows Load returns, e.g. for a "top 100"
+// dataset together with WithOrder. It does not apply to LoadSince, LoadMany
+// and LoadOne.
+func (l *GormLoader[T]) WithLimit(n int) *GormLoader[T] {
+	l.limit = n
+	return l
+}
+
+// WithOffset skips the first n rows Load would return. Like WithLimit it
+// does not apply to LoadSince, LoadMany and LoadOne.
+func (l *GormLoader[T]) WithOffset(n int) *GormLoader[T] {
+	l.offset = n
+	return l
+}
+
+// WithAfterLoad sets a hook that transforms the loaded rows before Load and
+// LoadSince return them, e.g. to decrypt a field or compute a derived value.
+// An error from the hook fails the load.
+func (l *GormLoader[T]) WithAfterLoad(hook func([]T) ([]T, error)) *GormLoader[T] {
+	l.afterLoad = hook
+	return l
+}
+
 // Load implements DataLoader interface
 func (l *GormLoader[T]) Load() ([]T, error) {
-	var items []T
+	return l.LoadCtx(context.Background())
+}
+
+// LoadCtx loads like Load with the queries bound to ctx, so that a
+// cancelled ctx or an expired deadline aborts them
+func (l *GormLoader[T]) LoadCtx(ctx context.Context) ([]T, error) {
+	query, err := l.buildQuery()
+	if err != nil {
+		return nil, err
+	}
+	if l.query == nil {
+		if l.limit > 0 {
+			query = query.Limit(l.limit)
+		}
+		if l.offset > 0 {
+			query = query.Offset(l.offset)
+		}
+	}
+	return l.find(query.WithContext(ctx))
+}
+
+// LoadSince loads only the rows whose column is newer than since, on top of
+// the configured query. It implements the cache's IncrementalLoader interface.
+func (l *GormLoader[T]) LoadSince(column string, since time.Time) ([]T, error) {
+	if !columnPattern.MatchString(column) {
+		return nil, fmt.Errorf("invalid column %q", column)
+	}
+	query, err := l.buildQuery()
+	if err != nil {
+		return nil, err
+	}
+	return l.find(query.Where(fmt.Sprintf("%s > ?", column), since))
+}
+
+// LoadMany loads only the rows with the given primary keys, on top of the
+// configured query, in a single IN query. It implements the cache's
+// BatchLoader interface.
+func (l *GormLoader[T]) LoadMany(ids []uint) ([]T, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	table, key, err := l.tableAndKey()
+	if err != nil {
+		return nil, err
+	}
+	query, err := l.buildQuery()
+	if err != nil {
+		return nil, err
+	}
+	return l.find(query.Where(fmt.Sprintf("%s.%s IN ?", table, key), ids))
+}
+
+// LoadOne loads the item with the given primary key, applying the loader's
+// conditions, preloads and joins. found is false if no row matches.
+func (l *GormLoader[T]) LoadOne(id uint) (item T, found bool, err error) {
+	table, key, err := l.tableAndKey()
+	if err != nil {
+		return item, false, err
+	}
+	query, err := l.buildQuery()
+	if err != nil {
+		return item, false, err
+	}
+	items, err := l.find(query.Where(fmt.Sprintf("%s.%s = ?", table, key), id).Limit(1))
+	if err != nil || len(items) == 0 {
+		return item, false, err
+	}
+	return items[0], true, nil
+}
+
+// buildQuery applies the configured options to a new query
+func (l *GormLoader[T]) buildQuery() (*gorm.DB, error) {
+	if l.query != nil {
+		// A new session lets the prebuilt query be executed repeatedly
+		query := l.query.Session(&gorm.Session{})
+		if l.debug {
+			query = query.Debug()
+		}
+		return query, nil
+	}
+
 	query := l.db.Model(&l.model) // Ensure the model is set for the query
 
+	// Pin the query to the replicas when requested
+	if l.replica {
+		query = query.Clauses(dbresolver.Read)
+	}
+
 	// Add joins if any
 	for _, join := range l.joins {
 		query = query.Joins(join)
@@ -133,15 +381,303 @@ func (l *GormLoader[T]) Load() ([]T, error) {
 		}
 	}
 
+	// Add JSON column conditions
+	for _, jc := range l.jsonConditions {
+		expr, err := jsonConditionSQL(l.db.Dialector.Name(), jc)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(expr, jc.value)
+	}
+
+	// Add full-text searches
+	for _, fts := range l.searches {
+		expr, args, err := fullTextSearchSQL(l.db.Dialector.Name(), fts)
+		if err != nil {
+			return nil, err
+		}
+		if expr != "" {
+			query = query.Where(expr, args...)
+		}
+	}
+
+	// Add EXISTS subqueries
+	if len(l.exists) > 0 {
+		table, key, err := l.tableAndKey()
+		if err != nil {
+			return nil, err
+		}
+		for _, ex := range l.exists {
+			sub, err := ex.sub.correlatedQuery(table, key, ex.foreignKey)
+			if err != nil {
+				return nil, err
+			}
+			query = query.Where("EXISTS (?)", sub)
+		}
+	}
+
+	// Restrict the selected columns
+	if len(l.selects) > 0 {
+		for _, column := range l.selects {
+			if !columnPattern.MatchString(column) {
+				return nil, fmt.Errorf("invalid select column %q", column)
+			}
+		}
+		query = query.Select(l.selects)
+	}
+
+	// Add ordering
+	for _, order := range l.orders {
+		query = query.Order(order)
+	}
+
+	// Tag the query with the comment
+	if l.comment != "" {
+		query = query.Clauses(queryComment(l.comment))
+	}
+
 	// Enable debug mode if requested
 	if l.debug {
 		query = query.Debug()
 	}
 
+	return query, nil
+}
+
+// correlatedQuery implements SubQuery
+func (l *GormLoader[T]) correlatedQuery(parentTable, parentKey, foreignKey string) (*gorm.DB, error) {
+	if !columnPattern.MatchString(foreignKey) {
+		return nil, fmt.Errorf("invalid foreign key column %q", foreignKey)
+	}
+	query, err := l.buildQuery()
+	if err != nil {
+		return nil, err
+	}
+	table, _, err := l.tableAndKey()
+	if err != nil {
+		return nil, err
+	}
+	return query.Select("1").Where(fmt.Sprintf("%s.%s = %s.%s", table, foreignKey, parentTable, parentKey)), nil
+}
+
+// tableAndKey returns the table name and primary key column of the model
+func (l *GormLoader[T]) tableAndKey() (string, string, error) {
+	stmt := &gorm.Statement{DB: l.db}
+	if err := stmt.Parse(&l.model); err != nil {
+		return "", "", fmt.Errorf("failed to parse model: %w", err)
+	}
+	if stmt.Schema.PrioritizedPrimaryField == nil {
+		return "", "", fmt.Errorf("model %s has no primary key", stmt.Schema.Name)
+	}
+	return stmt.Schema.Table, stmt.Schema.PrioritizedPrimaryField.DBName, nil
+}
+
+// find executes the query and scans the result
+func (l *GormLoader[T]) find(query *gorm.DB) ([]T, error) {
+	var items []T
 	result := query.Find(&items)
 	if result.Error != nil {
 		return nil, fmt.Errorf("failed to load data: %w", result.Error)
 	}
 
+	for _, cp := range l.condPreloads {
+		if err := l.preloadWhere(query.Statement.Context, items, cp); err != nil {
+			return nil, err
+		}
+	}
+
+	if l.afterLoad != nil {
+		transformed, err := l.afterLoad(items)
+		if err != nil {
+			return nil, fmt.Errorf("after load hook failed: %w", err)
+		}
+		items = transformed
+	}
+
 	return items, nil
 }
+
+// preloadWhere attaches the children of a has-one or has-many relation to
+// the items matching the preload's predicate
+func (l *GormLoader[T]) preloadWhere(ctx context.Context, items []T, cp conditionalPreload[T]) error {
+	stmt := &gorm.Statement{DB: l.db}
+	if err := stmt.Parse(&l.model); err != nil {
+		return fmt.Errorf("failed to parse model: %w", err)
+	}
+	rel, ok := stmt.Schema.Relationships.Relations[cp.relation]
+	if !ok {
+		return fmt.Errorf("unknown relation %q", cp.relation)
+	}
+	if (rel.Type != schema.HasOne && rel.Type != schema.HasMany) || len(rel.References) != 1 || !rel.References[0].OwnPrimaryKey {
+		return fmt.Errorf("conditional preload only supports has-one and has-many relations, got %s for %q", rel.Type, cp.relation)
+	}
+	ref := rel.References[0]
+
+	// Collect the selected parents and their keys
+	var parents []reflect.Value
+	var keys []interface{}
+	for i := range items {
+		if !cp.predicate(items[i]) {
+			continue
+		}
+		parent := reflect.Indirect(reflect.ValueOf(&items[i]).Elem())
+		key, zero := ref.PrimaryKey.ValueOf(ctx, parent)
+		if zero {
+			continue
+		}
+		parents = append(parents, parent)
+		keys = append(keys, key)
+	}
+	if len(parents) == 0 {
+		return nil
+	}
+
+	// Load the children of all selected parents in one query
+	children := reflect.New(reflect.SliceOf(rel.FieldSchema.ModelType))
+	query := l.db.WithContext(ctx)
+	if l.replica {
+		query = query.Clauses(dbresolver.Read)
+	}
+	if l.debug {
+		query = query.Debug()
+	}
+	result := query.Where(fmt.Sprintf("%s IN ?", ref.ForeignKey.DBName), keys).Find(children.Interface())
+	if result.Error != nil {
+		return fmt.Errorf("failed to preload %s: %w", cp.relation, result.Error)
+	}
+
+	byKey := make(map[string][]reflect.Value)
+	for i := 0; i < children.Elem().Len(); i++ {
+		child := children.Elem().Index(i)
+		key, _ := ref.ForeignKey.ValueOf(ctx, child)
+		byKey[fmt.Sprint(key)] = append(byKey[fmt.Sprint(key)], child)
+	}
+
+	fieldType := rel.Field.FieldType
+	for i, parent := range parents {
+		matched := byKey[fmt.Sprint(keys[i])]
+		var value reflect.Value
+		if rel.Type == schema.HasMany {
+			value = reflect.MakeSlice(fieldType, 0, len(matched))
+			for _, child := range matched {
+				value = reflect.Append(value, asElem(child, fieldType.Elem()))
+			}
+		} else if len(matched) > 0 {
+			value = asElem(matched[0], fieldType)
+		} else {
+			continue
+		}
+		if err := rel.Field.Set(ctx, parent, value.Interface()); err != nil {
+			return fmt.Errorf("failed to set %s: %w", cp.relation, err)
+		}
+	}
+	return nil
+}
+
+// asElem converts a loaded child struct to the relation's element type,
+// which may be a pointer to it
+func asElem(child reflect.Value, elemType reflect.Type) reflect.Value {
+	if elemType.Kind() == reflect.Ptr {
+		ptr := reflect.New(child.Type())
+		ptr.Elem().Set(child)
+		return ptr
+	}
+	return child
+}
+
+// queryComment is a clause that renders a SQL comment before SELECT
+type queryComment string
+
+// ModifyStatement implements gorm.StatementModifier
+func (c queryComment) ModifyStatement(stmt *gorm.Statement) {
+	selectClause := stmt.Clauses["SELECT"]
+	selectClause.BeforeExpression = c
+	stmt.Clauses["SELECT"] = selectClause
+}
+
+// Build implements clause.Expression
+func (c queryComment) Build(builder clause.Builder) {
+	builder.WriteString("/* " + string(c) + " */")
+}
+
+// sanitizeComment makes a string safe to embed in a SQL comment by removing
+// comment delimiters and control characters and collapsing whitespace
+func sanitizeComment(comment string) string {
+	comment = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, comment)
+	for strings.Contains(comment, "/*") || strings.Contains(comment, "*/") {
+		comment = strings.NewReplacer("/*", "", "*/", "").Replace(comment)
+	}
+	return strings.Join(strings.Fields(comment), " ")
+}
+
+// jsonConditionSQL builds the dialect specific WHERE fragment for a JSON condition
+func jsonConditionSQL(dialect string, jc jsonCondition) (string, error) {
+	if !columnPattern.MatchString(jc.column) {
+		return "", fmt.Errorf("invalid JSON column %q", jc.column)
+	}
+	if !jsonPathPattern.MatchString(jc.path) {
+		return "", fmt.Errorf("invalid JSON path %q", jc.path)
+	}
+	op := strings.ToUpper(strings.TrimSpace(jc.op))
+	if !jsonOperators[op] {
+		return "", fmt.Errorf("unsupported JSON condition operator %q", jc.op)
+	}
+
+	segments := strings.Split(jc.path, ".")
+	switch dialect {
+	case "postgres":
+		if len(segments) == 1 {
+			return fmt.Sprintf("%s->>'%s' %s ?", jc.column, jc.path, op), nil
+		}
+		return fmt.Sprintf("%s#>>'{%s}' %s ?", jc.column, strings.Join(segments, ","), op), nil
+	case "mysql":
+		return fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(%s, '$.%s')) %s ?", jc.column, jc.path, op), nil
+	default:
+		return "", fmt.Errorf("JSON conditions are not supported for dialect %s", dialect)
+	}
+}
+
+// fullTextSearchSQL builds the dialect specific WHERE fragment for a full-text
+// search. A blank term yields no fragment.
+func fullTextSearchSQL(dialect string, fts fullTextSearch) (string, []interface{}, error) {
+	if len(fts.columns) == 0 {
+		return "", nil, fmt.Errorf("full-text search needs at least one column")
+	}
+	for _, column := range fts.columns {
+		if !columnPattern.MatchString(column) {
+			return "", nil, fmt.Errorf("invalid full-text search column %q", column)
+		}
+	}
+	words := strings.Fields(fts.term)
+	if len(words) == 0 {
+		return "", nil, nil
+	}
+
+	switch dialect {
+	case "postgres":
+		parts := make([]string, len(fts.columns))
+		for i, column := range fts.columns {
+			parts[i] = fmt.Sprintf("coalesce(%s, '')", column)
+		}
+		return fmt.Sprintf("to_tsvector(%s) @@ plainto_tsquery(?)", strings.Join(parts, " || ' ' || ")), []interface{}{fts.term}, nil
+	case "mysql":
+		return fmt.Sprintf("MATCH (%s) AGAINST (? IN NATURAL LANGUAGE MODE)", strings.Join(fts.columns, ", ")), []interface{}{fts.term}, nil
+	default:
+		var clauses []string
+		var args []interface{}
+		for _, word := range words {
+			likes := make([]string, len(fts.columns))
+			for i, column := range fts.columns {
+				likes[i] = fmt.Sprintf("%s LIKE ?", column)
+				args = append(args, "%"+word+"%")
+			}
+			clauses = append(clauses, "("+strings.Join(likes, " OR ")+")")
+		}
+		return strings.Join(clauses, " AND "), args, nil
+	}
+}
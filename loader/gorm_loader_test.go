@@ -1,14 +1,20 @@
 package loader
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/costa92/multicache/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
 func setupTestDB(t *testing.T) *gorm.DB {
@@ -188,3 +194,527 @@ func TestGormLoader(t *testing.T) {
 		}
 	})
 }
+
+func TestGormLoaderWithReplica(t *testing.T) {
+	db := setupTestDB(t)
+
+	replicaPath := filepath.Join(t.TempDir(), "replica.db")
+	replica, err := gorm.Open(sqlite.Open(replicaPath), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, replica.AutoMigrate(&models.UserV2{}, &models.Order{}))
+	require.NoError(t, replica.Create(&models.UserV2{ID: 10, Name: "Replica", Email: "replica@example.com"}).Error)
+
+	err = db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: []gorm.Dialector{sqlite.Open(replicaPath)},
+	}))
+	require.NoError(t, err)
+
+	// A session pinned to the primary keeps reading from it unless the loader overrides it
+	primary := db.Clauses(dbresolver.Write).Session(&gorm.Session{})
+
+	t.Run("load without replica uses primary", func(t *testing.T) {
+		users, err := NewGormLoader(primary, models.UserV2{}).Load()
+		require.NoError(t, err)
+		assert.Len(t, users, 3, "should load users from the primary")
+	})
+
+	t.Run("load with replica uses replica", func(t *testing.T) {
+		users, err := NewGormLoader(primary, models.UserV2{}).WithReplica().Load()
+		require.NoError(t, err)
+		require.Len(t, users, 1, "should load users from the replica")
+		assert.Equal(t, "Replica", users[0].Name)
+	})
+
+	t.Run("load with replica without resolver", func(t *testing.T) {
+		users, err := NewGormLoader(setupTestDB(t), models.UserV2{}).WithReplica().Load()
+		require.NoError(t, err)
+		assert.Len(t, users, 3, "should fall back to the default pool")
+	})
+}
+
+// namedDialector runs on SQLite while reporting another dialect name, so
+// dialect specific SQL can be generated and inspected without that database
+type namedDialector struct {
+	gorm.Dialector
+	name string
+}
+
+func (d namedDialector) Name() string {
+	return d.name
+}
+
+// captureQueries records the SQL of every query executed through db
+func captureQueries(t *testing.T, db *gorm.DB) *[]string {
+	var queries []string
+	err := db.Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+		queries = append(queries, tx.Statement.SQL.String())
+	})
+	require.NoError(t, err)
+	return &queries
+}
+
+type customer struct {
+	ID       uint `gorm:"primaryKey"`
+	Name     string
+	Metadata string
+}
+
+func setupCustomerDB(t *testing.T, dialect string) *gorm.DB {
+	db, err := gorm.Open(namedDialector{Dialector: sqlite.Open(":memory:"), name: dialect}, &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&customer{}))
+
+	customers := []customer{
+		{ID: 1, Name: "Alice", Metadata: `{"tier":"gold","address":{"city":"Paris"}}`},
+		{ID: 2, Name: "Bob", Metadata: `{"tier":"silver","address":{"city":"Berlin"}}`},
+		{ID: 3, Name: "Carol", Metadata: `{"tier":"gold","address":{"city":"Rome"}}`},
+	}
+	require.NoError(t, db.Create(&customers).Error)
+	return db
+}
+
+func TestGormLoaderWithJSONCondition(t *testing.T) {
+	t.Run("postgres filters on a JSON key", func(t *testing.T) {
+		db := setupCustomerDB(t, "postgres")
+		customers, err := NewGormLoader(db, customer{}).
+			WithJSONCondition("metadata", "tier", "=", "gold").
+			Load()
+		require.NoError(t, err)
+		assert.Len(t, customers, 2, "should return gold customers")
+		for _, c := range customers {
+			assert.Contains(t, c.Metadata, `"tier":"gold"`)
+		}
+	})
+
+	t.Run("postgres nested path", func(t *testing.T) {
+		db := setupCustomerDB(t, "postgres")
+		queries := captureQueries(t, db)
+		_, err := NewGormLoader(db.Session(&gorm.Session{DryRun: true}), customer{}).
+			WithJSONCondition("metadata", "address.city", "=", "Paris").
+			Load()
+		require.NoError(t, err)
+		require.Len(t, *queries, 1)
+		assert.Contains(t, (*queries)[0], "metadata#>>'{address,city}' = ?")
+	})
+
+	t.Run("mysql uses JSON_EXTRACT", func(t *testing.T) {
+		db := setupCustomerDB(t, "mysql")
+		queries := captureQueries(t, db)
+		_, err := NewGormLoader(db.Session(&gorm.Session{DryRun: true}), customer{}).
+			WithJSONCondition("metadata", "address.city", "=", "Paris").
+			Load()
+		require.NoError(t, err)
+		require.Len(t, *queries, 1)
+		assert.Contains(t, (*queries)[0], "JSON_UNQUOTE(JSON_EXTRACT(metadata, '$.address.city')) = ?")
+	})
+
+	t.Run("sqlite is unsupported", func(t *testing.T) {
+		_, err := NewGormLoader(setupTestDB(t), models.UserV2{}).
+			WithJSONCondition("metadata", "tier", "=", "gold").
+			Load()
+		assert.EqualError(t, err, "JSON conditions are not supported for dialect sqlite")
+	})
+
+	t.Run("rejects unsafe input", func(t *testing.T) {
+		db := setupCustomerDB(t, "postgres")
+		_, err := NewGormLoader(db, customer{}).
+			WithJSONCondition("metadata", "tier' OR '1'='1", "=", "gold").
+			Load()
+		assert.Error(t, err)
+
+		_, err = NewGormLoader(db, customer{}).
+			WithJSONCondition("metadata", "tier", "; DROP", "gold").
+			Load()
+		assert.Error(t, err)
+	})
+}
+
+func TestGormLoaderLoadSince(t *testing.T) {
+	db := setupTestDB(t)
+	watermark := time.Now()
+	require.NoError(t, db.Model(&models.Order{}).Where("id IN ?", []uint{1, 2}).
+		Update("created_at", watermark.Add(-time.Hour)).Error)
+	require.NoError(t, db.Model(&models.Order{}).Where("id IN ?", []uint{3, 4}).
+		Update("created_at", watermark.Add(time.Hour)).Error)
+
+	t.Run("loads rows newer than the watermark", func(t *testing.T) {
+		orders, err := NewGormLoader(db, models.Order{}).LoadSince("created_at", watermark)
+		require.NoError(t, err)
+		assert.Len(t, orders, 2)
+		for _, order := range orders {
+			assert.Contains(t, []uint{3, 4}, order.ID)
+		}
+	})
+
+	t.Run("combines with the configured condition", func(t *testing.T) {
+		orders, err := NewGormLoader(db, models.Order{}).
+			WithCondition("user_id = ?", 2).
+			LoadSince("created_at", watermark)
+		require.NoError(t, err)
+		require.Len(t, orders, 1)
+		assert.Equal(t, uint(3), orders[0].ID)
+	})
+
+	t.Run("rejects an invalid column", func(t *testing.T) {
+		_, err := NewGormLoader(db, models.Order{}).LoadSince("created_at; DROP TABLE orders", watermark)
+		assert.Error(t, err)
+	})
+}
+
+func TestGormLoaderWithFullTextSearch(t *testing.T) {
+	t.Run("postgres uses tsvector", func(t *testing.T) {
+		db := setupCustomerDB(t, "postgres")
+		queries := captureQueries(t, db)
+		_, err := NewGormLoader(db.Session(&gorm.Session{DryRun: true}), customer{}).
+			WithFullTextSearch([]string{"name", "metadata"}, "gold paris").
+			Load()
+		require.NoError(t, err)
+		require.Len(t, *queries, 1)
+		assert.Contains(t, (*queries)[0], "to_tsvector(coalesce(name, '') || ' ' || coalesce(metadata, '')) @@ plainto_tsquery(?)")
+	})
+
+	t.Run("mysql uses MATCH AGAINST", func(t *testing.T) {
+		db := setupCustomerDB(t, "mysql")
+		queries := captureQueries(t, db)
+		_, err := NewGormLoader(db.Session(&gorm.Session{DryRun: true}), customer{}).
+			WithFullTextSearch([]string{"name", "metadata"}, "gold paris").
+			Load()
+		require.NoError(t, err)
+		require.Len(t, *queries, 1)
+		assert.Contains(t, (*queries)[0], "MATCH (name, metadata) AGAINST (? IN NATURAL LANGUAGE MODE)")
+	})
+
+	t.Run("other dialects require every word", func(t *testing.T) {
+		db := setupCustomerDB(t, "sqlite")
+		customers, err := NewGormLoader(db, customer{}).
+			WithFullTextSearch([]string{"name", "metadata"}, "gold Paris").
+			Load()
+		require.NoError(t, err)
+		require.Len(t, customers, 1)
+		assert.Equal(t, "Alice", customers[0].Name)
+	})
+
+	t.Run("blank term matches everything", func(t *testing.T) {
+		db := setupCustomerDB(t, "sqlite")
+		customers, err := NewGormLoader(db, customer{}).
+			WithFullTextSearch([]string{"name"}, "  ").
+			Load()
+		require.NoError(t, err)
+		assert.Len(t, customers, 3)
+	})
+
+	t.Run("rejects invalid columns", func(t *testing.T) {
+		db := setupCustomerDB(t, "postgres")
+		_, err := NewGormLoader(db, customer{}).
+			WithFullTextSearch([]string{"name) OR (1=1"}, "gold").
+			Load()
+		assert.Error(t, err)
+	})
+}
+
+func TestGormLoaderWithExists(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.Create(&models.UserV2{ID: 4, Name: "Nobody", Email: "nobody@example.com"}).Error)
+
+	userIDs := func(users []models.UserV2) []uint {
+		ids := make([]uint, len(users))
+		for i, user := range users {
+			ids[i] = user.ID
+		}
+		return ids
+	}
+
+	t.Run("users with at least one order", func(t *testing.T) {
+		users, err := NewGormLoader(db, models.UserV2{}).
+			WithExists(NewGormLoader(db, models.Order{}), "user_id").
+			Load()
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []uint{1, 2, 3}, userIDs(users), "users must not be duplicated per order")
+	})
+
+	t.Run("sub loader conditions apply", func(t *testing.T) {
+		users, err := NewGormLoader(db, models.UserV2{}).
+			WithExists(NewGormLoader(db, models.Order{}).WithCondition("amount > ?", 250), "user_id").
+			Load()
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []uint{2, 3}, userIDs(users))
+	})
+
+	t.Run("invalid foreign key", func(t *testing.T) {
+		_, err := NewGormLoader(db, models.UserV2{}).
+			WithExists(NewGormLoader(db, models.Order{}), "user_id; DROP TABLE orders").
+			Load()
+		assert.Error(t, err)
+	})
+}
+
+// member is a user with an active flag, used for conditional preloads
+type member struct {
+	ID     uint `gorm:"primaryKey"`
+	Name   string
+	Active bool
+	Orders []models.Order `gorm:"foreignKey:UserID"`
+}
+
+func TestGormLoaderWithConditionalPreload(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&member{}, &models.Order{}))
+	require.NoError(t, db.Create(&[]member{
+		{ID: 1, Name: "John", Active: true},
+		{ID: 2, Name: "Jane", Active: false},
+		{ID: 3, Name: "Bob", Active: true},
+	}).Error)
+	require.NoError(t, db.Create(&[]models.Order{
+		{ID: 1, UserID: 1, Amount: 100},
+		{ID: 2, UserID: 1, Amount: 200},
+		{ID: 3, UserID: 2, Amount: 300},
+	}).Error)
+
+	queries := captureQueries(t, db)
+	members, err := NewGormLoader(db, member{}).
+		WithConditionalPreload("Orders", func(m member) bool { return m.Active }).
+		Load()
+	require.NoError(t, err)
+	require.Len(t, members, 3)
+
+	for _, m := range members {
+		switch m.ID {
+		case 1:
+			assert.Len(t, m.Orders, 2, "active users get their orders")
+		case 2:
+			assert.Nil(t, m.Orders, "inactive users have no orders loaded")
+		case 3:
+			assert.NotNil(t, m.Orders, "active users without orders get an empty slice")
+			assert.Empty(t, m.Orders)
+		}
+	}
+
+	require.Len(t, *queries, 2, "one parent query and one batched child query")
+	assert.Contains(t, (*queries)[1], "user_id IN (?,?)")
+
+	t.Run("rejects unknown relations", func(t *testing.T) {
+		_, err := NewGormLoader(db, member{}).
+			WithConditionalPreload("Profiles", func(member) bool { return true }).
+			Load()
+		assert.Error(t, err)
+	})
+}
+
+func TestGormLoaderWithQueryComment(t *testing.T) {
+	db := setupTestDB(t)
+	queries := captureQueries(t, db)
+
+	users, err := NewGormLoader(db, models.UserV2{}).
+		WithCondition("name = ?", "John").
+		WithQueryComment("cache:user_cache").
+		Load()
+	require.NoError(t, err)
+	assert.Len(t, users, 1)
+	require.Len(t, *queries, 1)
+	assert.True(t, strings.HasPrefix((*queries)[0], "/* cache:user_cache */ SELECT"), (*queries)[0])
+
+	t.Run("sanitizes the comment", func(t *testing.T) {
+		assert.Equal(t, "x DROP TABLE users;", sanitizeComment("x */ DROP TABLE users; /*"))
+		assert.Equal(t, "a/b", sanitizeComment("a*/*//b"))
+		assert.Equal(t, "a b", sanitizeComment("a\n\tb"))
+
+		*queries = nil
+		_, err := NewGormLoader(db, models.UserV2{}).
+			WithQueryComment("*/ DELETE FROM users; /*").
+			Load()
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix((*queries)[0], "/* DELETE FROM users; */ SELECT"), (*queries)[0])
+	})
+}
+
+func TestGormLoaderWithAfterLoad(t *testing.T) {
+	db := setupTestDB(t)
+
+	t.Run("transforms the loaded rows", func(t *testing.T) {
+		users, err := NewGormLoader(db, models.UserV2{}).
+			WithAfterLoad(func(users []models.UserV2) ([]models.UserV2, error) {
+				for i := range users {
+					users[i].Email = strings.ToUpper(users[i].Email)
+				}
+				return users, nil
+			}).
+			Load()
+		require.NoError(t, err)
+		require.Len(t, users, 3)
+		for _, user := range users {
+			assert.Equal(t, strings.ToUpper(user.Email), user.Email)
+		}
+	})
+
+	t.Run("may drop rows", func(t *testing.T) {
+		users, err := NewGormLoader(db, models.UserV2{}).
+			WithAfterLoad(func(users []models.UserV2) ([]models.UserV2, error) {
+				return users[:1], nil
+			}).
+			Load()
+		require.NoError(t, err)
+		assert.Len(t, users, 1)
+	})
+
+	t.Run("propagates the hook error", func(t *testing.T) {
+		hookErr := errors.New("decryption failed")
+		users, err := NewGormLoader(db, models.UserV2{}).
+			WithAfterLoad(func(users []models.UserV2) ([]models.UserV2, error) {
+				return nil, hookErr
+			}).
+			Load()
+		assert.ErrorIs(t, err, hookErr)
+		assert.Nil(t, users)
+	})
+}
+
+func TestGormLoaderLoadMany(t *testing.T) {
+	db := setupTestDB(t)
+	queries := captureQueries(t, db)
+
+	users, err := NewGormLoader(db, models.UserV2{}).LoadMany([]uint{1, 3, 99})
+	require.NoError(t, err)
+	ids := make([]uint, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	assert.ElementsMatch(t, []uint{1, 3}, ids, "missing IDs are left out")
+	require.Len(t, *queries, 1)
+	assert.Contains(t, (*queries)[0], "IN (?,?,?)")
+
+	t.Run("keeps the configured conditions", func(t *testing.T) {
+		users, err := NewGormLoader(db, models.UserV2{}).
+			WithCondition("name LIKE ?", "John%").
+			LoadMany([]uint{1, 2})
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, uint(1), users[0].ID)
+	})
+
+	t.Run("no IDs", func(t *testing.T) {
+		users, err := NewGormLoader(db, models.UserV2{}).LoadMany(nil)
+		require.NoError(t, err)
+		assert.Empty(t, users)
+	})
+}
+
+func TestGormLoaderWithSelect(t *testing.T) {
+	db := setupTestDB(t)
+
+	users, err := NewGormLoader(db, models.UserV2{}).WithSelect("id", "name").WithPreload("Orders").Load()
+	require.NoError(t, err)
+	require.Len(t, users, 3)
+	for _, user := range users {
+		assert.NotZero(t, user.ID)
+		assert.NotEmpty(t, user.Name)
+		assert.Empty(t, user.Email, "unselected columns stay zero")
+		assert.NotEmpty(t, user.Orders, "preloads still work")
+	}
+
+	t.Run("qualified columns with joins", func(t *testing.T) {
+		orders, err := NewGormLoader(db, models.Order{}).
+			WithJoinsModel(models.UserV2{}, "orders.user_id", "user_v2.id").
+			WithSelect("orders.id", "orders.amount").
+			WithCondition("user_v2.name = ?", "Jane").
+			Load()
+		require.NoError(t, err)
+		require.Len(t, orders, 1)
+		assert.Equal(t, float64(300), orders[0].Amount)
+		assert.Zero(t, orders[0].UserID)
+	})
+
+	t.Run("rejects invalid columns", func(t *testing.T) {
+		_, err := NewGormLoader(db, models.UserV2{}).WithSelect("id; DROP TABLE user_v2").Load()
+		assert.ErrorContains(t, err, "invalid select column")
+	})
+}
+
+func TestGormLoaderWithOrderAndLimit(t *testing.T) {
+	db := setupTestDB(t)
+	amounts := func(orders []models.Order) []float64 {
+		result := make([]float64, len(orders))
+		for i, order := range orders {
+			result[i] = order.Amount
+		}
+		return result
+	}
+
+	orders, err := NewGormLoader(db, models.Order{}).WithOrder("amount DESC").WithLimit(2).Load()
+	require.NoError(t, err)
+	assert.Equal(t, []float64{400, 300}, amounts(orders))
+
+	t.Run("offset pages through the rows", func(t *testing.T) {
+		orders, err := NewGormLoader(db, models.Order{}).WithOrder("amount DESC").WithLimit(2).WithOffset(2).Load()
+		require.NoError(t, err)
+		assert.Equal(t, []float64{200, 100}, amounts(orders))
+	})
+
+	t.Run("orders chain", func(t *testing.T) {
+		queries := captureQueries(t, db)
+		orders, err := NewGormLoader(db, models.Order{}).WithOrder("user_id").WithOrder("amount DESC").Load()
+		require.NoError(t, err)
+		assert.Equal(t, []float64{200, 100, 300, 400}, amounts(orders))
+		assert.Contains(t, (*queries)[0], "ORDER BY user_id,amount DESC")
+	})
+
+	t.Run("LoadMany ignores the limit", func(t *testing.T) {
+		orders, err := NewGormLoader(db, models.Order{}).WithLimit(1).LoadMany([]uint{1, 2, 3})
+		require.NoError(t, err)
+		assert.Len(t, orders, 3)
+	})
+}
+
+func TestNewGormLoaderFromQuery(t *testing.T) {
+	db := setupTestDB(t)
+	bigSpenders := db.Model(&models.Order{}).Select("user_id").Where("amount >= ?", 300)
+	query := db.Model(&models.UserV2{}).Where("id IN (?)", bigSpenders).Order("id DESC")
+
+	loader := NewGormLoaderFromQuery[models.UserV2](query).WithDebug(true)
+	for i := 0; i < 2; i++ {
+		users, err := loader.Load()
+		require.NoError(t, err, "the query can be run repeatedly")
+		require.Len(t, users, 2)
+		assert.Equal(t, uint(3), users[0].ID)
+		assert.Equal(t, uint(2), users[1].ID)
+	}
+
+	t.Run("builder conditions are ignored", func(t *testing.T) {
+		users, err := NewGormLoaderFromQuery[models.UserV2](query).WithCondition("id = ?", 3).Load()
+		require.NoError(t, err)
+		assert.Len(t, users, 2)
+	})
+}
+
+func TestGormLoaderLoadCtx(t *testing.T) {
+	db := setupTestDB(t)
+
+	users, err := NewGormLoader(db, models.UserV2{}).LoadCtx(context.Background())
+	require.NoError(t, err)
+	assert.NotEmpty(t, users)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = NewGormLoader(db, models.UserV2{}).WithPreload("Orders").LoadCtx(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestGormLoaderLoadOne(t *testing.T) {
+	db := setupTestDB(t)
+	queries := captureQueries(t, db)
+
+	user, found, err := NewGormLoader(db, models.UserV2{}).WithPreload("Orders").LoadOne(1)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, uint(1), user.ID)
+	assert.NotEmpty(t, user.Orders)
+	assert.Contains(t, strings.Join(*queries, "\n"), "user_v2.id = ?")
+
+	_, found, err = NewGormLoader(db, models.UserV2{}).LoadOne(99)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	_, found, err = NewGormLoader(db, models.UserV2{}).WithCondition("name LIKE ?", "John%").LoadOne(2)
+	require.NoError(t, err)
+	assert.False(t, found, "conditions still apply")
+}
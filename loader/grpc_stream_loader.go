@@ -0,0 +1,80 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MessageStream is the receiving side of a server-streaming gRPC call.
+// Generated client streams satisfy it, so no gRPC dependency is needed here.
+type MessageStream[M any] interface {
+	Recv() (M, error)
+}
+
+// GRPCStreamLoader implements DataLoader interface by draining a gRPC
+// server stream and mapping each message to T
+type GRPCStreamLoader[M any, T any] struct {
+	ctx    context.Context
+	open   func(ctx context.Context) (MessageStream[M], error)
+	mapper func(M) (T, error)
+	debug  bool
+}
+
+// NewGRPCStreamLoader creates a new gRPC stream data loader. open starts
+// the stream for each Load, e.g.
+//
+//	func(ctx context.Context) (loader.MessageStream[*pb.User], error) {
+//		return client.ListUsers(ctx, &pb.ListUsersRequest{})
+//	}
+func NewGRPCStreamLoader[M any, T any](ctx context.Context, open func(ctx context.Context) (MessageStream[M], error), mapper func(M) (T, error)) *GRPCStreamLoader[M, T] {
+	return &GRPCStreamLoader[M, T]{
+		ctx:    ctx,
+		open:   open,
+		mapper: mapper,
+	}
+}
+
+// WithDebug enables debug mode for the loader
+func (l *GRPCStreamLoader[M, T]) WithDebug(debug bool) *GRPCStreamLoader[M, T] {
+	l.debug = debug
+	return l
+}
+
+// Load implements DataLoader interface
+func (l *GRPCStreamLoader[M, T]) Load() ([]T, error) {
+	if err := l.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	stream, err := l.open(l.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stream: %w", err)
+	}
+
+	var items []T
+	for {
+		if err := l.ctx.Err(); err != nil {
+			return nil, err
+		}
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to receive message: %w", err)
+		}
+		item, err := l.mapper(msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to map message %d: %w", len(items), err)
+		}
+		items = append(items, item)
+	}
+
+	if l.debug {
+		fmt.Printf("gRPC stream drained: %d messages\n", len(items))
+	}
+
+	return items, nil
+}
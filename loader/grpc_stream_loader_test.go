@@ -0,0 +1,93 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type userMessage struct {
+	Id   uint32
+	Name string
+}
+
+// fakeStream mimics a generated server-streaming client
+type fakeStream struct {
+	msgs []*userMessage
+	err  error
+}
+
+func (s *fakeStream) Recv() (*userMessage, error) {
+	if len(s.msgs) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	msg := s.msgs[0]
+	s.msgs = s.msgs[1:]
+	return msg, nil
+}
+
+func mapUserMessage(msg *userMessage) (models.User, error) {
+	if msg.Name == "" {
+		return models.User{}, errors.New("missing name")
+	}
+	return models.User{ID: uint(msg.Id), Name: msg.Name}, nil
+}
+
+func openUsers(msgs []*userMessage, err error) func(context.Context) (MessageStream[*userMessage], error) {
+	return func(ctx context.Context) (MessageStream[*userMessage], error) {
+		return &fakeStream{msgs: append([]*userMessage(nil), msgs...), err: err}, nil
+	}
+}
+
+func TestGRPCStreamLoader(t *testing.T) {
+	msgs := []*userMessage{{Id: 1, Name: "John"}, {Id: 2, Name: "Jane"}}
+
+	t.Run("drains the stream", func(t *testing.T) {
+		loader := NewGRPCStreamLoader(context.Background(), openUsers(msgs, nil), mapUserMessage).WithDebug(true)
+		users, err := loader.Load()
+		require.NoError(t, err)
+		require.Len(t, users, 2)
+		assert.Equal(t, "Jane", users[1].Name)
+
+		// Each load opens a new stream
+		users, err = loader.Load()
+		require.NoError(t, err)
+		assert.Len(t, users, 2)
+	})
+
+	t.Run("wraps stream errors", func(t *testing.T) {
+		streamErr := errors.New("connection reset")
+		_, err := NewGRPCStreamLoader(context.Background(), openUsers(msgs, streamErr), mapUserMessage).Load()
+		assert.ErrorIs(t, err, streamErr)
+		assert.Contains(t, err.Error(), "failed to receive message")
+	})
+
+	t.Run("wraps open errors", func(t *testing.T) {
+		open := func(ctx context.Context) (MessageStream[*userMessage], error) {
+			return nil, errors.New("unavailable")
+		}
+		_, err := NewGRPCStreamLoader(context.Background(), open, mapUserMessage).Load()
+		assert.EqualError(t, err, "failed to open stream: unavailable")
+	})
+
+	t.Run("reports mapping errors", func(t *testing.T) {
+		bad := append(msgs, &userMessage{Id: 3})
+		_, err := NewGRPCStreamLoader(context.Background(), openUsers(bad, nil), mapUserMessage).Load()
+		assert.EqualError(t, err, "failed to map message 2: missing name")
+	})
+
+	t.Run("honors a cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := NewGRPCStreamLoader(ctx, openUsers(msgs, nil), mapUserMessage).Load()
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
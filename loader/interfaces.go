@@ -1,7 +1,10 @@
 package loader
 
 import (
+	"context"
+
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"gorm.io/gorm"
 )
 
@@ -10,6 +13,13 @@ type DataLoader[T any] interface {
 	Load() ([]T, error)
 }
 
+// ContextLoader is a DataLoader whose loads can be bound to a context for
+// cancellation, deadlines and tracing
+type ContextLoader[T any] interface {
+	DataLoader[T]
+	LoadCtx(ctx context.Context) ([]T, error)
+}
+
 // GormLoaderOption defines the interface for GORM loader options
 type GormLoaderOption interface {
 	Apply(*gorm.DB) *gorm.DB
@@ -43,6 +53,11 @@ type MongoDataLoader[T any] interface {
 	WithFilter(filter interface{}) MongoDataLoader[T]
 	WithOptions(opts interface{}) MongoDataLoader[T]
 	WithAggregate(pipeline mongo.Pipeline) MongoDataLoader[T]
+	WithSkip(n int64) MongoDataLoader[T]
+	WithProjection(projection interface{}) MongoDataLoader[T]
+	WithCollation(collation *options.Collation) MongoDataLoader[T]
+	LoadCtx(ctx context.Context) ([]T, error)
+	Count() (int64, error)
 }
 
 // JoinModel represents a join model configuration
@@ -79,8 +94,11 @@ type GormLoaderConfig struct {
 // MongoLoaderConfig represents the configuration for MongoDB loader
 type MongoLoaderConfig struct {
 	LoaderConfig
-	Filter    interface{}
-	Options   interface{}
-	Pipeline  mongo.Pipeline
-	Aggregate bool
+	Filter     interface{}
+	Options    interface{}
+	Pipeline   mongo.Pipeline
+	Aggregate  bool
+	Skip       int64
+	Projection interface{}
+	Collation  *options.Collation
 }
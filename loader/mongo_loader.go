@@ -11,14 +11,17 @@ import (
 
 // MongoLoader implements MongoDataLoader interface
 type MongoLoader[T any] struct {
-	coll      *mongo.Collection
-	ctx       context.Context
-	filter    interface{}
-	opts      *options.FindOptions
-	pipeline  mongo.Pipeline
-	aggregate bool
-	debug     bool
-	config    MongoLoaderConfig
+	coll       *mongo.Collection
+	ctx        context.Context
+	filter     interface{}
+	opts       *options.FindOptions
+	pipeline   mongo.Pipeline
+	aggregate  bool
+	skip       int64
+	projection interface{}
+	collation  *options.Collation
+	debug      bool
+	config     MongoLoaderConfig
 }
 
 // NewMongoLoader creates a new MongoDB data loader
@@ -66,31 +69,137 @@ func (l *MongoLoader[T]) WithAggregate(pipeline mongo.Pipeline) MongoDataLoader[
 	return l
 }
 
-// Load implements DataLoader interface
+// WithSkip implements MongoDataLoader interface. It skips the first n
+// documents of the result, after the sort, and composes with a limit set by
+// WithOptions in either order, e.g. to page through a large collection.
+// With an aggregation it appends a $skip stage.
+func (l *MongoLoader[T]) WithSkip(n int64) MongoDataLoader[T] {
+	l.skip = n
+	l.config.Skip = n
+	return l
+}
+
+// WithProjection implements MongoDataLoader interface. It limits the
+// loaded fields, e.g. bson.M{"name": 1, "email": 1}, so slim cache models
+// can be built from large documents. With an aggregation it appends a
+// $project stage.
+func (l *MongoLoader[T]) WithProjection(projection interface{}) MongoDataLoader[T] {
+	l.projection = projection
+	l.config.Projection = projection
+	return l
+}
+
+// WithCollation implements MongoDataLoader interface. The collation, e.g.
+// for case-insensitive matching and sorting, applies to the find or
+// aggregation of every load and to Count.
+func (l *MongoLoader[T]) WithCollation(collation *options.Collation) MongoDataLoader[T] {
+	l.collation = collation
+	l.config.Collation = collation
+	return l
+}
+
+// Count returns the number of documents matching the filter, or produced
+// by the aggregation pipeline, ignoring the skip and limit, e.g. the total
+// for paging
+func (l *MongoLoader[T]) Count() (int64, error) {
+	if !l.aggregate {
+		count, err := l.coll.CountDocuments(l.ctx, l.filter, options.Count().SetCollation(l.collation))
+		if err != nil {
+			return 0, fmt.Errorf("failed to count documents: %w", err)
+		}
+		return count, nil
+	}
+
+	cursor, err := l.coll.Aggregate(l.ctx, l.countPipeline(), l.aggregateOptions())
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer cursor.Close(l.ctx)
+
+	var results []struct {
+		Count int64 `bson:"count"`
+	}
+	if err := cursor.All(l.ctx, &results); err != nil {
+		return 0, fmt.Errorf("failed to decode results: %w", err)
+	}
+	if len(results) == 0 {
+		return 0, nil
+	}
+	return results[0].Count, nil
+}
+
+// findOptions returns the find options with the skip, projection and
+// collation applied, leaving the options passed to WithOptions untouched
+func (l *MongoLoader[T]) findOptions() *options.FindOptions {
+	if l.skip == 0 && l.projection == nil && l.collation == nil {
+		return l.opts
+	}
+	opts := *l.opts
+	if l.collation != nil {
+		opts.SetCollation(l.collation)
+	}
+	if l.skip > 0 {
+		opts.SetSkip(l.skip)
+	}
+	if l.projection != nil {
+		opts.SetProjection(l.projection)
+	}
+	return &opts
+}
+
+func (l *MongoLoader[T]) aggregateOptions() *options.AggregateOptions {
+	return options.Aggregate().SetCollation(l.collation)
+}
+
+// aggregatePipeline returns the pipeline with the projection and skip
+// stages appended
+func (l *MongoLoader[T]) aggregatePipeline() mongo.Pipeline {
+	pipeline := append(mongo.Pipeline{}, l.pipeline...)
+	if l.projection != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$project", Value: l.projection}})
+	}
+	if l.skip > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: l.skip}})
+	}
+	return pipeline
+}
+
+func (l *MongoLoader[T]) countPipeline() mongo.Pipeline {
+	return append(append(mongo.Pipeline{}, l.pipeline...), bson.D{{Key: "$count", Value: "count"}})
+}
+
+// Load implements DataLoader interface using the context the loader was
+// created with
 func (l *MongoLoader[T]) Load() ([]T, error) {
+	return l.LoadCtx(l.ctx)
+}
+
+// LoadCtx loads like Load but runs the query and decodes the results with
+// ctx instead of the loader's context
+func (l *MongoLoader[T]) LoadCtx(ctx context.Context) ([]T, error) {
 	var items []T
 	var cursor *mongo.Cursor
 	var err error
 
 	if l.debug {
-		fmt.Printf("MongoDB Query: filter=%v, aggregate=%v\n", l.filter, l.aggregate)
+		fmt.Printf("MongoDB Query: filter=%v, projection=%v, aggregate=%v\n", l.filter, l.projection, l.aggregate)
 		if l.aggregate {
-			fmt.Printf("Pipeline: %v\n", l.pipeline)
+			fmt.Printf("Pipeline: %v\n", l.aggregatePipeline())
 		}
 	}
 
 	if l.aggregate {
-		cursor, err = l.coll.Aggregate(l.ctx, l.pipeline)
+		cursor, err = l.coll.Aggregate(ctx, l.aggregatePipeline(), l.aggregateOptions())
 	} else {
-		cursor, err = l.coll.Find(l.ctx, l.filter, l.opts)
+		cursor, err = l.coll.Find(ctx, l.filter, l.findOptions())
 	}
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
-	defer cursor.Close(l.ctx)
+	defer cursor.Close(ctx)
 
-	if err := cursor.All(l.ctx, &items); err != nil {
+	if err := cursor.All(ctx, &items); err != nil {
 		return nil, fmt.Errorf("failed to decode results: %w", err)
 	}
 
@@ -106,15 +215,13 @@ func (o MongoOption) Apply(coll *mongo.Collection) *mongo.Collection {
 	return o.fn(coll)
 }
 
-// WithIndex creates a MongoDB index option
+// WithIndex creates a MongoDB index option. Only the first IndexOptions is
+// used. A failure to create the index is printed and otherwise ignored; use
+// EnsureIndex to handle it.
 func WithIndex(keys interface{}, opts ...*options.IndexOptions) MongoLoaderOption {
 	return MongoOption{
 		fn: func(coll *mongo.Collection) *mongo.Collection {
-			_, err := coll.Indexes().CreateOne(context.Background(), mongo.IndexModel{
-				Keys:    keys,
-				Options: opts[0],
-			})
-			if err != nil {
+			if err := createIndex(context.Background(), coll, keys, opts); err != nil {
 				// Log error but don't fail
 				fmt.Printf("Failed to create index: %v\n", err)
 			}
@@ -123,19 +230,24 @@ func WithIndex(keys interface{}, opts ...*options.IndexOptions) MongoLoaderOptio
 	}
 }
 
-// WithCollation creates a MongoDB collation option
-func WithCollation(collation *options.Collation) MongoLoaderOption {
-	return MongoOption{
-		fn: func(coll *mongo.Collection) *mongo.Collection {
-			// Directly set the collation on the collection options
-			collationOpts := options.Find().SetCollation(collation)
-			// Apply the collation options to the collection
-			cursor, err := coll.Find(context.Background(), bson.D{}, collationOpts)
-			if err != nil {
-				return coll
-			}
-			defer cursor.Close(context.Background())
-			return coll
-		},
+// EnsureIndex creates an index on coll unless it already exists. Only the
+// first IndexOptions is used.
+func EnsureIndex(ctx context.Context, coll *mongo.Collection, keys interface{}, opts ...*options.IndexOptions) error {
+	if err := createIndex(ctx, coll, keys, opts); err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+	return nil
+}
+
+func createIndex(ctx context.Context, coll *mongo.Collection, keys interface{}, opts []*options.IndexOptions) error {
+	_, err := coll.Indexes().CreateOne(ctx, indexModel(keys, opts))
+	return err
+}
+
+func indexModel(keys interface{}, opts []*options.IndexOptions) mongo.IndexModel {
+	model := mongo.IndexModel{Keys: keys}
+	if len(opts) > 0 {
+		model.Options = opts[0]
 	}
+	return model
 }
@@ -88,4 +88,135 @@ func TestMongoLoader(t *testing.T) {
 		assert.Len(t, orders, 2)
 		assert.Equal(t, float64(200), orders[0].Amount)
 	})
+
+	t.Run("load a page with skip and limit", func(t *testing.T) {
+		coll := client.Database("testdb").Collection("orders")
+		loader := NewMongoLoader[models.Order](ctx, coll).
+			WithSkip(1).
+			WithOptions(options.Find().SetSort(bson.M{"amount": 1}).SetLimit(1))
+		orders, err := loader.Load()
+		require.NoError(t, err)
+		require.Len(t, orders, 1)
+		assert.Equal(t, float64(200), orders[0].Amount)
+
+		total, err := loader.Count()
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), total, "the count ignores skip and limit")
+	})
+
+	t.Run("count with filter and aggregation", func(t *testing.T) {
+		coll := client.Database("testdb").Collection("orders")
+		count, err := NewMongoLoader[models.Order](ctx, coll).
+			WithFilter(bson.M{"user_id": 1}).
+			Count()
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+
+		count, err = NewMongoLoader[models.Order](ctx, coll).
+			WithAggregate(mongo.Pipeline{bson.D{{Key: "$match", Value: bson.M{"user_id": 2}}}}).
+			Count()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("load with projection", func(t *testing.T) {
+		coll := client.Database("testdb").Collection("users")
+		users, err := NewMongoLoader[models.User](ctx, coll).
+			WithProjection(bson.M{"id": 1, "name": 1}).
+			Load()
+		require.NoError(t, err)
+		require.Len(t, users, 2)
+		assert.NotEmpty(t, users[0].Name)
+		assert.Empty(t, users[0].Email, "unprojected fields are not loaded")
+	})
+
+	t.Run("load with collation", func(t *testing.T) {
+		coll := client.Database("testdb").Collection("users")
+		loader := NewMongoLoader[models.User](ctx, coll).
+			WithFilter(bson.M{"name": "JOHN"}).
+			WithCollation(&options.Collation{Locale: "en", Strength: 2})
+		users, err := loader.Load()
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, "John", users[0].Name)
+
+		count, err := loader.Count()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("create index without options", func(t *testing.T) {
+		coll := client.Database("testdb").Collection("orders")
+		assert.NotPanics(t, func() { WithIndex(bson.D{{Key: "user_id", Value: 1}}).Apply(coll) })
+		require.NoError(t, EnsureIndex(ctx, coll, bson.D{{Key: "amount", Value: -1}}))
+
+		err := EnsureIndex(ctx, coll, bson.D{{Key: "amount", Value: -1}}, options.Index().SetName("amount_1"))
+		assert.ErrorContains(t, err, "failed to create index", "conflicting index names are reported")
+	})
+}
+
+func TestMongoLoaderSkip(t *testing.T) {
+	t.Run("composes with find options", func(t *testing.T) {
+		opts := options.Find().SetLimit(10)
+		loader := NewMongoLoader[models.Order](context.Background(), nil).
+			WithOptions(opts).
+			WithSkip(20).(*MongoLoader[models.Order])
+
+		find := loader.findOptions()
+		assert.Equal(t, int64(20), *find.Skip)
+		assert.Equal(t, int64(10), *find.Limit)
+		assert.Nil(t, opts.Skip, "the caller's options are not modified")
+	})
+
+	t.Run("appends pipeline stages", func(t *testing.T) {
+		match := bson.D{{Key: "$match", Value: bson.M{"user_id": 1}}}
+		loader := NewMongoLoader[models.Order](context.Background(), nil).
+			WithAggregate(mongo.Pipeline{match}).
+			WithSkip(5).(*MongoLoader[models.Order])
+
+		assert.Equal(t, mongo.Pipeline{match, {{Key: "$skip", Value: int64(5)}}}, loader.aggregatePipeline())
+		assert.Equal(t, mongo.Pipeline{match, {{Key: "$count", Value: "count"}}}, loader.countPipeline())
+		assert.Len(t, loader.pipeline, 1)
+	})
+}
+
+func TestMongoLoaderProjection(t *testing.T) {
+	projection := bson.M{"name": 1}
+
+	t.Run("sets the find projection", func(t *testing.T) {
+		loader := NewMongoLoader[models.User](context.Background(), nil).
+			WithProjection(projection).(*MongoLoader[models.User])
+		assert.Equal(t, projection, loader.findOptions().Projection)
+		assert.Equal(t, projection, loader.config.Projection)
+	})
+
+	t.Run("appends a project stage", func(t *testing.T) {
+		loader := NewMongoLoader[models.User](context.Background(), nil).
+			WithAggregate(mongo.Pipeline{}).
+			WithProjection(projection).(*MongoLoader[models.User])
+		assert.Equal(t, mongo.Pipeline{{{Key: "$project", Value: projection}}}, loader.aggregatePipeline())
+	})
+}
+
+func TestMongoLoaderCollation(t *testing.T) {
+	collation := &options.Collation{Locale: "en", Strength: 2}
+	loader := NewMongoLoader[models.User](context.Background(), nil).
+		WithOptions(options.Find().SetLimit(5)).
+		WithCollation(collation).(*MongoLoader[models.User])
+
+	assert.Equal(t, collation, loader.findOptions().Collation)
+	assert.Equal(t, int64(5), *loader.findOptions().Limit)
+	assert.Equal(t, collation, loader.aggregateOptions().Collation)
+	assert.Nil(t, loader.opts.Collation, "the caller's options are not modified")
+}
+
+func TestMongoIndexModel(t *testing.T) {
+	keys := bson.D{{Key: "user_id", Value: 1}}
+
+	model := indexModel(keys, nil)
+	assert.Equal(t, keys, model.Keys)
+	assert.Nil(t, model.Options, "no options are passed without IndexOptions")
+
+	unique := options.Index().SetUnique(true)
+	assert.Same(t, unique, indexModel(keys, []*options.IndexOptions{unique}).Options)
 }
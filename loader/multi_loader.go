@@ -0,0 +1,65 @@
+package loader
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MultiLoader implements DataLoader interface by combining several loaders,
+// e.g. one per shard or collection
+type MultiLoader[T any] struct {
+	loaders     []DataLoader[T]
+	concurrency int
+}
+
+// NewMultiLoader creates a loader that concatenates the results of the
+// given loaders in order
+func NewMultiLoader[T any](loaders ...DataLoader[T]) *MultiLoader[T] {
+	return &MultiLoader[T]{
+		loaders:     loaders,
+		concurrency: 1,
+	}
+}
+
+// WithConcurrency bounds how many child loads run in parallel. The default
+// of 1 loads the children sequentially.
+func (l *MultiLoader[T]) WithConcurrency(n int) *MultiLoader[T] {
+	if n < 1 {
+		n = 1
+	}
+	l.concurrency = n
+	return l
+}
+
+// Load implements DataLoader interface
+func (l *MultiLoader[T]) Load() ([]T, error) {
+	results := make([][]T, len(l.loaders))
+	errs := make([]error, len(l.loaders))
+
+	sem := make(chan struct{}, l.concurrency)
+	var wg sync.WaitGroup
+	for i, child := range l.loaders {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, child DataLoader[T]) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i], errs[i] = child.Load()
+		}(i, child)
+	}
+	wg.Wait()
+
+	total := 0
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("failed to load from loader %d: %w", i, err)
+		}
+		total += len(results[i])
+	}
+
+	items := make([]T, 0, total)
+	for _, result := range results {
+		items = append(items, result...)
+	}
+	return items, nil
+}
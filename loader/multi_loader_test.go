@@ -0,0 +1,81 @@
+package loader
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingLoader tracks how many loads run at the same time
+type recordingLoader struct {
+	orders  []models.Order
+	err     error
+	running *atomic.Int32
+	peak    *atomic.Int32
+}
+
+func (l *recordingLoader) Load() ([]models.Order, error) {
+	n := l.running.Add(1)
+	defer l.running.Add(-1)
+	for {
+		peak := l.peak.Load()
+		if n <= peak || l.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return l.orders, l.err
+}
+
+func newRecordingLoaders(n int) ([]DataLoader[models.Order], *atomic.Int32) {
+	running, peak := &atomic.Int32{}, &atomic.Int32{}
+	loaders := make([]DataLoader[models.Order], n)
+	for i := range loaders {
+		loaders[i] = &recordingLoader{
+			orders:  []models.Order{{ID: uint(i + 1), UserID: 1, Amount: float64(i * 100)}},
+			running: running,
+			peak:    peak,
+		}
+	}
+	return loaders, peak
+}
+
+func TestMultiLoader(t *testing.T) {
+	t.Run("concatenates results in order", func(t *testing.T) {
+		loaders, _ := newRecordingLoaders(3)
+		orders, err := NewMultiLoader(loaders...).Load()
+		require.NoError(t, err)
+		require.Len(t, orders, 3)
+		for i, order := range orders {
+			assert.Equal(t, uint(i+1), order.ID)
+		}
+	})
+
+	t.Run("loads sequentially by default", func(t *testing.T) {
+		loaders, peak := newRecordingLoaders(4)
+		_, err := NewMultiLoader(loaders...).Load()
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), peak.Load())
+	})
+
+	t.Run("bounds concurrent loads", func(t *testing.T) {
+		loaders, peak := newRecordingLoaders(8)
+		orders, err := NewMultiLoader(loaders...).WithConcurrency(3).Load()
+		require.NoError(t, err)
+		assert.Len(t, orders, 8)
+		assert.LessOrEqual(t, peak.Load(), int32(3))
+		assert.Greater(t, peak.Load(), int32(1), "loads should overlap")
+	})
+
+	t.Run("returns child errors", func(t *testing.T) {
+		loaders, _ := newRecordingLoaders(3)
+		loaders[1].(*recordingLoader).err = errors.New("shard unavailable")
+		_, err := NewMultiLoader(loaders...).WithConcurrency(2).Load()
+		assert.EqualError(t, err, "failed to load from loader 1: shard unavailable")
+	})
+}
@@ -0,0 +1,70 @@
+package loader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrReaderConsumed is returned by NDJSONLoader.Load once a reader that
+// cannot seek has been read, since returning no items would empty the cache
+var ErrReaderConsumed = errors.New("ndjson reader already consumed")
+
+// NDJSONLoader implements DataLoader interface by decoding newline-delimited
+// JSON, one item per line, e.g. from a bulk export. The input is read line
+// by line, so only the decoded items are held in memory. If the reader is an
+// io.Seeker, e.g. an *os.File, each Load rereads it from where it started;
+// otherwise loads after the first fail with ErrReaderConsumed.
+type NDJSONLoader[T any] struct {
+	r      io.Reader
+	start  int64 // offset of a seekable reader when the loader was created
+	loaded bool
+}
+
+// NewNDJSONLoader creates a loader that reads items from r
+func NewNDJSONLoader[T any](r io.Reader) *NDJSONLoader[T] {
+	l := &NDJSONLoader[T]{r: r}
+	if s, ok := r.(io.Seeker); ok {
+		if start, err := s.Seek(0, io.SeekCurrent); err == nil {
+			l.start = start
+		}
+	}
+	return l
+}
+
+// Load implements DataLoader interface. Blank lines are skipped; a line that
+// does not decode fails the load with its line number.
+func (l *NDJSONLoader[T]) Load() ([]T, error) {
+	if l.loaded {
+		s, ok := l.r.(io.Seeker)
+		if !ok {
+			return nil, ErrReaderConsumed
+		}
+		if _, err := s.Seek(l.start, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to rewind reader: %w", err)
+		}
+	}
+	l.loaded = true
+
+	r := bufio.NewReader(l.r)
+	items := make([]T, 0)
+	for lineNo := 1; ; lineNo++ {
+		line, err := r.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to read line %d: %w", lineNo, err)
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var item T
+			if decodeErr := json.Unmarshal(line, &item); decodeErr != nil {
+				return nil, fmt.Errorf("failed to decode line %d: %w", lineNo, decodeErr)
+			}
+			items = append(items, item)
+		}
+		if err != nil {
+			return items, nil
+		}
+	}
+}
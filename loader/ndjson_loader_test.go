@@ -0,0 +1,62 @@
+package loader
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNDJSONLoader(t *testing.T) {
+	payload := `{"id": 1, "name": "John", "email": "john@example.com"}
+
+{"id": 2, "name": "Jane", "email": "jane@example.com"}
+   
+{"id": 3, "name": "Bob", "email": "bob@example.com"}`
+
+	users, err := NewNDJSONLoader[models.User](strings.NewReader(payload)).Load()
+	require.NoError(t, err)
+	require.Len(t, users, 3)
+	assert.Equal(t, "John", users[0].Name)
+	assert.Equal(t, uint(3), users[2].ID)
+
+	t.Run("reports the malformed line", func(t *testing.T) {
+		payload := `{"id": 1, "name": "John"}
+{"id": 2, "name": "Jane"}
+
+{"id": 3, "name": }
+{"id": 4, "name": "Alice"}
+`
+		_, err := NewNDJSONLoader[models.User](strings.NewReader(payload)).Load()
+		assert.ErrorContains(t, err, "line 4")
+	})
+
+	t.Run("rereads a seekable reader", func(t *testing.T) {
+		loader := NewNDJSONLoader[models.User](strings.NewReader(payload))
+		for i := 0; i < 2; i++ {
+			users, err := loader.Load()
+			require.NoError(t, err)
+			assert.Len(t, users, 3)
+		}
+	})
+
+	t.Run("fails once a plain reader is consumed", func(t *testing.T) {
+		loader := NewNDJSONLoader[models.User](io.MultiReader(strings.NewReader(payload)))
+		users, err := loader.Load()
+		require.NoError(t, err)
+		assert.Len(t, users, 3)
+
+		users, err = loader.Load()
+		assert.ErrorIs(t, err, ErrReaderConsumed)
+		assert.Nil(t, users)
+	})
+
+	t.Run("empty input", func(t *testing.T) {
+		users, err := NewNDJSONLoader[models.User](strings.NewReader("")).Load()
+		assert.NoError(t, err)
+		assert.Empty(t, users)
+	})
+}
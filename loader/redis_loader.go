@@ -0,0 +1,135 @@
+package loader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// redisBatchSize is how many keys are fetched per MGET
+const redisBatchSize = 500
+
+// RedisClient is the subset of Redis commands the RedisLoader needs, with
+// the results unwrapped, so no Redis dependency is needed here. A go-redis
+// client is adapted by calling Result() on each command, e.g.
+//
+//	func (c adapter) MGet(ctx context.Context, keys ...string) ([]interface{}, error) {
+//		return c.Client.MGet(ctx, keys...).Result()
+//	}
+type RedisClient interface {
+	Scan(ctx context.Context, cursor uint64, match string, count int64) (keys []string, next uint64, err error)
+	SMembers(ctx context.Context, key string) ([]string, error)
+	MGet(ctx context.Context, keys ...string) ([]interface{}, error)
+}
+
+// RedisLoader implements DataLoader interface by reading JSON encoded
+// values from Redis. The keys to read are the members of a set, or with
+// WithKeyPattern the keys matching a pattern, found with SCAN.
+type RedisLoader[T any] struct {
+	ctx     context.Context
+	client  RedisClient
+	setKey  string
+	pattern string
+	debug   bool
+}
+
+// NewRedisLoader creates a loader reading the keys listed in the set setKey.
+// Pass an empty setKey when the keys are discovered with WithKeyPattern.
+func NewRedisLoader[T any](ctx context.Context, client RedisClient, setKey string) *RedisLoader[T] {
+	return &RedisLoader[T]{
+		ctx:    ctx,
+		client: client,
+		setKey: setKey,
+	}
+}
+
+// WithKeyPattern discovers the keys to read with SCAN MATCH pattern, e.g.
+// "country:*", instead of reading them from a set
+func (l *RedisLoader[T]) WithKeyPattern(pattern string) *RedisLoader[T] {
+	l.pattern = pattern
+	return l
+}
+
+// WithDebug enables debug mode for the loader
+func (l *RedisLoader[T]) WithDebug(debug bool) *RedisLoader[T] {
+	l.debug = debug
+	return l
+}
+
+// Load implements DataLoader interface
+func (l *RedisLoader[T]) Load() ([]T, error) {
+	return l.LoadCtx(l.ctx)
+}
+
+// LoadCtx loads like Load with the Redis commands bound to ctx. Keys that
+// disappear between their discovery and the read are skipped.
+func (l *RedisLoader[T]) LoadCtx(ctx context.Context) ([]T, error) {
+	keys, err := l.keys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if l.debug {
+		fmt.Printf("Redis Load: set=%q, pattern=%q, keys=%d\n", l.setKey, l.pattern, len(keys))
+	}
+
+	items := make([]T, 0, len(keys))
+	for start := 0; start < len(keys); start += redisBatchSize {
+		batch := keys[start:min(start+redisBatchSize, len(keys))]
+		values, err := l.client.MGet(ctx, batch...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keys: %w", err)
+		}
+		for i, value := range values {
+			if value == nil {
+				continue
+			}
+			var raw []byte
+			switch v := value.(type) {
+			case string:
+				raw = []byte(v)
+			case []byte:
+				raw = v
+			default:
+				return nil, fmt.Errorf("unexpected value type %T for key %q", value, batch[i])
+			}
+			var item T
+			if err := json.Unmarshal(raw, &item); err != nil {
+				return nil, fmt.Errorf("failed to decode key %q: %w", batch[i], err)
+			}
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// keys returns the keys to read, from the set or a full SCAN
+func (l *RedisLoader[T]) keys(ctx context.Context) ([]string, error) {
+	if l.pattern == "" {
+		keys, err := l.client.SMembers(ctx, l.setKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read set %q: %w", l.setKey, err)
+		}
+		return keys, nil
+	}
+
+	// SCAN may return a key more than once, so deduplicate
+	seen := make(map[string]struct{})
+	var keys []string
+	var cursor uint64
+	for {
+		batch, next, err := l.client.Scan(ctx, cursor, l.pattern, redisBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan keys: %w", err)
+		}
+		for _, key := range batch {
+			if _, dup := seen[key]; !dup {
+				seen[key] = struct{}{}
+				keys = append(keys, key)
+			}
+		}
+		if next == 0 {
+			return keys, nil
+		}
+		cursor = next
+	}
+}
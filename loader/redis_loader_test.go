@@ -0,0 +1,114 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"testing"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis is an in-memory RedisClient whose SCAN returns one key per page
+type fakeRedis struct {
+	values map[string]string
+	sets   map[string][]string
+	err    error
+}
+
+func (r *fakeRedis) sortedKeys() []string {
+	keys := make([]string, 0, len(r.values))
+	for key := range r.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (r *fakeRedis) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	if r.err != nil {
+		return nil, 0, r.err
+	}
+	keys := r.sortedKeys()
+	if cursor >= uint64(len(keys)) {
+		return nil, 0, nil
+	}
+	var page []string
+	if ok, _ := path.Match(match, keys[cursor]); ok {
+		page = append(page, keys[cursor])
+	}
+	next := cursor + 1
+	if next == uint64(len(keys)) {
+		next = 0
+	}
+	return page, next, nil
+}
+
+func (r *fakeRedis) SMembers(ctx context.Context, key string) ([]string, error) {
+	return r.sets[key], r.err
+}
+
+func (r *fakeRedis) MGet(ctx context.Context, keys ...string) ([]interface{}, error) {
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		if value, ok := r.values[key]; ok {
+			values[i] = value
+		}
+	}
+	return values, r.err
+}
+
+func TestRedisLoader(t *testing.T) {
+	client := &fakeRedis{
+		values: map[string]string{
+			"user:1":  `{"id": 1, "name": "John"}`,
+			"user:2":  `{"id": 2, "name": "Jane"}`,
+			"order:1": `{"id": 1, "user_id": 1}`,
+		},
+		sets: map[string][]string{"users": {"user:1", "user:2", "user:9"}},
+	}
+
+	t.Run("reads the keys of a set", func(t *testing.T) {
+		users, err := NewRedisLoader[models.User](context.Background(), client, "users").Load()
+		require.NoError(t, err)
+		assert.Len(t, users, 2, "missing keys are skipped")
+	})
+
+	t.Run("scans keys matching a pattern", func(t *testing.T) {
+		users, err := NewRedisLoader[models.User](context.Background(), client, "").
+			WithKeyPattern("user:*").
+			Load()
+		require.NoError(t, err)
+		require.Len(t, users, 2)
+		assert.Equal(t, "John", users[0].Name)
+		assert.Equal(t, "Jane", users[1].Name)
+	})
+
+	t.Run("reports undecodable values", func(t *testing.T) {
+		client := &fakeRedis{values: map[string]string{"user:1": "not json"}}
+		_, err := NewRedisLoader[models.User](context.Background(), client, "").WithKeyPattern("user:*").Load()
+		assert.ErrorContains(t, err, `key "user:1"`)
+	})
+
+	t.Run("reports client errors", func(t *testing.T) {
+		client := &fakeRedis{err: errors.New("connection refused")}
+		_, err := NewRedisLoader[models.User](context.Background(), client, "users").Load()
+		assert.ErrorIs(t, err, client.err)
+	})
+
+	t.Run("reads in batches", func(t *testing.T) {
+		client := &fakeRedis{values: map[string]string{}, sets: map[string][]string{}}
+		for i := 0; i < 2*redisBatchSize+1; i++ {
+			key := fmt.Sprintf("user:%d", i)
+			client.values[key] = fmt.Sprintf(`{"id": %d}`, i)
+			client.sets["users"] = append(client.sets["users"], key)
+		}
+		users, err := NewRedisLoader[models.User](context.Background(), client, "users").Load()
+		require.NoError(t, err)
+		assert.Len(t, users, 2*redisBatchSize+1)
+	})
+}
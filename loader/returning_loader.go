@@ -0,0 +1,76 @@
+package loader
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReturningLoader implements DataLoader interface by running an
+// UPDATE ... RETURNING statement and loading the rows it modified, e.g. the
+// rows a batch job just touched. Every Load runs the update again, so it
+// should be idempotent or be used for a single Refresh.
+//
+// RETURNING is a PostgreSQL feature (SQLite 3.35+ supports it too); MySQL
+// has no equivalent and Load returns an error there.
+type ReturningLoader[T any] struct {
+	db        *gorm.DB
+	updates   map[string]interface{}
+	condition []interface{}
+	debug     bool
+}
+
+// NewReturningLoader creates a loader that applies updates to the rows of
+// the table of T matching the condition set with WithCondition
+func NewReturningLoader[T any](db *gorm.DB, updates map[string]interface{}) *ReturningLoader[T] {
+	return &ReturningLoader[T]{
+		db:      db,
+		updates: updates,
+	}
+}
+
+// WithCondition selects the rows to update. A condition is required;
+// GORM refuses updates without a WHERE clause.
+func (l *ReturningLoader[T]) WithCondition(query interface{}, args ...interface{}) *ReturningLoader[T] {
+	l.condition = append([]interface{}{query}, args...)
+	return l
+}
+
+// WithDebug enables debug mode for the loader
+func (l *ReturningLoader[T]) WithDebug(debug bool) *ReturningLoader[T] {
+	l.debug = debug
+	return l
+}
+
+// Load implements DataLoader interface
+func (l *ReturningLoader[T]) Load() ([]T, error) {
+	if !supportsReturning(l.db) {
+		return nil, fmt.Errorf("RETURNING is not supported for dialect %s", l.db.Dialector.Name())
+	}
+
+	var items []T
+	query := l.db.Model(&items).Clauses(clause.Returning{})
+	if len(l.condition) > 0 {
+		query = query.Where(l.condition[0], l.condition[1:]...)
+	}
+	if l.debug {
+		query = query.Debug()
+	}
+
+	if err := query.Updates(l.updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update data: %w", err)
+	}
+	return items, nil
+}
+
+// supportsReturning reports whether the dialect's update callback scans
+// RETURNING results
+func supportsReturning(db *gorm.DB) bool {
+	for _, name := range db.Callback().Update().Clauses {
+		if name == "RETURNING" {
+			return true
+		}
+	}
+	return false
+}
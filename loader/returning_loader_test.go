@@ -0,0 +1,44 @@
+package loader
+
+import (
+	"testing"
+
+	"github.com/costa92/multicache/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReturningLoader(t *testing.T) {
+	db := setupTestDB(t)
+
+	t.Run("returns the updated rows", func(t *testing.T) {
+		loader := NewReturningLoader[models.UserV2](db, map[string]interface{}{"email": "archived@example.com"}).
+			WithCondition("name LIKE ?", "John%")
+		users, err := loader.Load()
+		require.NoError(t, err)
+
+		ids := make([]uint, len(users))
+		for i, user := range users {
+			ids[i] = user.ID
+			assert.Equal(t, "archived@example.com", user.Email, "rows reflect the update")
+		}
+		assert.ElementsMatch(t, []uint{1, 3}, ids)
+
+		var untouched models.UserV2
+		require.NoError(t, db.First(&untouched, 2).Error)
+		assert.Equal(t, "jane@example.com", untouched.Email)
+	})
+
+	t.Run("no matching rows", func(t *testing.T) {
+		users, err := NewReturningLoader[models.UserV2](db, map[string]interface{}{"email": "x"}).
+			WithCondition("name = ?", "Nobody").
+			Load()
+		require.NoError(t, err)
+		assert.Empty(t, users)
+	})
+
+	t.Run("requires a condition", func(t *testing.T) {
+		_, err := NewReturningLoader[models.UserV2](db, map[string]interface{}{"email": "x"}).Load()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,38 @@
+package loader
+
+import "fmt"
+
+// TransformLoader implements DataLoader interface by mapping the rows of
+// another loader to a different type, e.g. storage rows to a cache model
+type TransformLoader[S, T any] struct {
+	inner     DataLoader[S]
+	transform func(S) (T, error)
+}
+
+// NewTransformLoader creates a loader that loads from inner and maps each
+// row with transform
+func NewTransformLoader[S, T any](inner DataLoader[S], transform func(S) (T, error)) *TransformLoader[S, T] {
+	return &TransformLoader[S, T]{
+		inner:     inner,
+		transform: transform,
+	}
+}
+
+// Load implements DataLoader interface. An error from any transform aborts
+// the load.
+func (l *TransformLoader[S, T]) Load() ([]T, error) {
+	rows, err := l.inner.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]T, 0, len(rows))
+	for i, row := range rows {
+		item, err := l.transform(row)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transform row %d: %w", i, err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,64 @@
+package loader
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// userRow is a storage row whose schema differs from the cached model
+type userRow struct {
+	UserID    uint
+	FirstName string
+	LastName  string
+	Password  string
+}
+
+type userDTO struct {
+	ID       uint
+	FullName string
+}
+
+type userRowLoader struct {
+	rows []userRow
+	err  error
+}
+
+func (l *userRowLoader) Load() ([]userRow, error) {
+	return l.rows, l.err
+}
+
+func toUserDTO(row userRow) (userDTO, error) {
+	if row.UserID == 0 {
+		return userDTO{}, errors.New("missing id")
+	}
+	return userDTO{ID: row.UserID, FullName: strings.TrimSpace(row.FirstName + " " + row.LastName)}, nil
+}
+
+func TestTransformLoader(t *testing.T) {
+	t.Run("maps each row", func(t *testing.T) {
+		inner := &userRowLoader{rows: []userRow{
+			{UserID: 1, FirstName: "John", LastName: "Doe", Password: "secret"},
+			{UserID: 2, FirstName: "Jane"},
+		}}
+		users, err := NewTransformLoader(inner, toUserDTO).Load()
+		require.NoError(t, err)
+		assert.Equal(t, []userDTO{{ID: 1, FullName: "John Doe"}, {ID: 2, FullName: "Jane"}}, users)
+	})
+
+	t.Run("aborts on a transform error", func(t *testing.T) {
+		inner := &userRowLoader{rows: []userRow{{UserID: 1}, {FirstName: "Nobody"}}}
+		users, err := NewTransformLoader(inner, toUserDTO).Load()
+		assert.EqualError(t, err, "failed to transform row 1: missing id")
+		assert.Nil(t, users)
+	})
+
+	t.Run("returns inner errors", func(t *testing.T) {
+		inner := &userRowLoader{err: errors.New("connection refused")}
+		_, err := NewTransformLoader(inner, toUserDTO).Load()
+		assert.EqualError(t, err, "connection refused")
+	})
+}
@@ -5,7 +5,7 @@ type UserV2 struct {
 	ID     uint    `json:"id" gorm:"primaryKey"`
 	Name   string  `json:"name"`
 	Email  string  `json:"email"`
-	Orders []Order `json:"orders"`
+	Orders []Order `json:"orders" gorm:"foreignKey:UserID"`
 }
 
 // GetID implements the Identifiable interface